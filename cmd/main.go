@@ -17,107 +17,248 @@ limitations under the License.
 package main
 
 import (
-	"context"
 	"flag"
 	"fmt"
 	"os"
+	"strings"
+	"time"
 
-	"github.com/go-git/go-git/v5/plumbing/transport/http"
-	"github.com/go-logr/logr"
 	"go.uber.org/zap/zapcore"
+	"gopkg.in/yaml.v2"
 	_ "k8s.io/client-go/plugin/pkg/client/auth"
-	"sigs.k8s.io/controller-runtime/pkg/healthz"
+	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
 	"sigs.k8s.io/controller-runtime/pkg/webhook"
-	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
 
+	"github.com/reborn1867/k8s-resource-tracer/pkg/backend"
+	"github.com/reborn1867/k8s-resource-tracer/pkg/deadletter"
 	"github.com/reborn1867/k8s-resource-tracer/pkg/git"
+	"github.com/reborn1867/k8s-resource-tracer/pkg/tracer"
+	"github.com/reborn1867/k8s-resource-tracer/pkg/vault"
 	"github.com/reborn1867/k8s-resource-tracer/pkg/webhooks/listener"
 )
 
+// version is set at build time with -ldflags "-X main.version=<version>"
+var version = "dev"
+
+// defaultBranch is the branch out of cluster, where KUBERNETES_SERVICE_HOST is not set
+const defaultBranch = "default"
+
+var (
+	// configEnvs are the environment variables read by the tracer
+	configEnvs = []string{"KUBERNETES_SERVICE_HOST", "GIT_USER_NAME", "GIT_PASSWORD", "REVIEW_API_TOKEN", "POD_NAME", "POD_NAMESPACE", "KAFKA_USERNAME", "KAFKA_PASSWORD",
+		"GIT_SSH_KEY_PATH", "GIT_SSH_KEY_PASSPHRASE", "GIT_TOKEN", "SSH_KNOWN_HOSTS", "HTTP_BACKEND_TOKEN", "AWS_REGION", "AWS_ACCESS_KEY_ID", "AWS_SECRET_ACCESS_KEY", "AWS_SESSION_TOKEN"}
+	secretEnvs = map[string]bool{"GIT_PASSWORD": true, "REVIEW_API_TOKEN": true, "KAFKA_PASSWORD": true, "GIT_SSH_KEY_PASSPHRASE": true, "GIT_TOKEN": true, "HTTP_BACKEND_TOKEN": true, "AWS_SECRET_ACCESS_KEY": true, "AWS_SESSION_TOKEN": true}
+)
+
 func main() {
 	var debug bool
-	var enableGitReview bool
-	var gitURL string
-	var gitPath string
-	var subPath string
-	var branch string
+	var printConfig bool
+	cfg := tracer.Config{PushOptions: git.DefaultPushOptions}
 
+	opts := zap.Options{
+		Development: true,
+	}
+
+	// the service host is the default branch in cluster, running out of cluster with --kubeconfig falls back to
+	// defaultBranch
+	k8sHost, inCluster := os.LookupEnv("KUBERNETES_SERVICE_HOST")
+	defaultBranchName := k8sHost
+	if !inCluster {
+		defaultBranchName = defaultBranch
+	}
+
+	flag.BoolVar(&debug, "debug", false, "Enable debug logging")
+	flag.IntVar(&cfg.Port, "port", webhook.DefaultPort, "port the webhook server listens on")
+	flag.StringVar(&cfg.Host, "host", "", "address the webhook server binds to, e.g. 127.0.0.1, empty means all interfaces")
+	flag.BoolVar(&printConfig, "printConfig", false, "print the effective configuration with secrets masked and exit")
+	flag.BoolVar(&cfg.EnableGitReview, "enableGitReview", false, "Enable git review")
+	flag.BoolVar(&cfg.DryRun, "dryRun", false, "log the changes which would be committed without committing or pushing them, the repository is still cloned")
+	flag.BoolVar(&cfg.IgnoreStatusChanges, "ignoreStatusChanges", false, "exclude status from diff and storage, status-only changes are not traced")
+	flag.BoolVar(&cfg.IgnoreStatusChanges, "ignoreStatus", false, "alias of ignoreStatusChanges")
+	flag.BoolVar(&cfg.CaptureFinalState, "captureFinalState", false, "add a finalizer to traced objects to record their final state before deletion, requires a mutating webhook")
+	flag.Var((*stringSlice)(&cfg.IncludeNamespaces), "includeNamespace", "namespace to trace, when set objects in other namespaces are admitted without tracing, can be repeated")
+	flag.Var((*stringSlice)(&cfg.ExcludeNamespaces), "excludeNamespace", "namespace never traced, wins over includeNamespace, e.g. kube-system, can be repeated")
+	flag.Var((*stringSlice)(&cfg.TraceGVKs), "traceGVK", "kind to trace in the form of group/version/Kind, e.g. apps/v1/Deployment or v1/ConfigMap for the core group, other kinds are admitted without tracing, can be repeated")
+	flag.StringVar(&cfg.ObjectSelector, "objectSelector", "", "label selector objects are traced by, e.g. audit=true or tier in (web,db), other objects are admitted without tracing")
+	flag.BoolVar(&cfg.RedactSecrets, "redactSecrets", true, "replace the values of data and stringData of secrets by a hash before diffing and storage")
+	flag.Var((*stringSlice)(&cfg.RedactPaths), "redactPath", "path replaced by "+listener.RedactedValue+" before objects are diffed and stored, e.g. spec.template.spec.containers[*].env, can be repeated")
+	flag.Var((*stringSlice)(&cfg.PartialRedactPaths), "partialRedactPath", "path to mask keeping length and hash of the values, e.g. spec.template.spec.containers[*].env, can be repeated")
+	flag.Var((*stringSlice)(&cfg.SetKeys), "setKeys", "list diffed regardless of the order of its items, path=key,key matches items by keys, e.g. spec.template.spec.containers=name, path alone diffs it as a multiset, can be repeated")
+	flag.Var((*stringSlice)(&cfg.DiffOptions), "diffOption", "option of every diff: set or multiset diff all lists regardless of the order of their items, precision=<number> ignores smaller changes of numbers, can be repeated")
+	flag.Var((*stringSlice)(&cfg.AllowPaths), "allowPath", "path to trace, when set everything else is dropped before diffing and storage, e.g. spec.replicas, can be repeated")
+	flag.IntVar(&cfg.MaxManagedFields, "maxManagedFields", listener.DefaultMaxManagedFields, "maximum number of managedFields entries looked at to find the latest manager, 0 means no limit")
+	flag.IntVar(&cfg.MaxObjectBytes, "maxObjectBytes", 0, "objects larger than this are admitted without being diffed or committed, 0 means no limit")
+	flag.StringVar(&cfg.CommitFormat, "commitFormat", listener.CommitFormatYAML, "format and file extension of committed objects: yaml or json, json files are indented and get no header")
+	flag.StringVar(&cfg.FailurePolicy, "failurePolicy", listener.FailureOpen, "open admits requests the tracer fails on, closed rejects them, including changes which could not be stored")
+	flag.StringVar(&cfg.OutputFormat, "outputFormat", listener.OutputColor, "format of the logged diffs: color, plain, json or unified, json logs every change with path, old and new value as structured fields, unified logs a diff of the yaml of the objects in the format of git diff")
+	flag.IntVar(&cfg.LongStringThreshold, "longStringThreshold", listener.DefaultLongStringThreshold, "strings longer than this are diffed line by line, 0 disables it")
+	flag.BoolVar(&cfg.MergeMetadataDiff, "mergeMetadataDiff", false, "render the changes of labels, annotations and the metadataDiffField fields as one metadata section")
+	flag.Var((*stringSlice)(&cfg.MetadataDiffFields), "metadataDiffField", "metadata field rendered in the merged metadata section, can be repeated, defaults to "+strings.Join(listener.DefaultMetadataDiffFields, ","))
+	flag.Var((*stringSlice)(&cfg.IgnoreFields), "ignoreField", "metadata field left out of diffs and storage in addition to "+strings.Join(listener.DefaultIgnoredFields, ",")+", can be repeated")
+	flag.Var((*stringSlice)(&cfg.StoredMetadataFields), "storeMetadataField", "metadata field kept in storage, * keeps all fields, can be repeated, defaults to "+strings.Join(listener.DefaultStoredMetadataFields, ","))
+	flag.BoolVar(&cfg.RecordChangeAuthor, "recordChangeAuthor", false, "annotate stored objects with the user, groups and time of their last change")
+	flag.StringVar(&cfg.ChangeAnnotationPrefix, "changeAnnotationPrefix", listener.DefaultChangeAnnotationPrefix, "prefix of the annotations written by recordChangeAuthor")
+	flag.BoolVar(&cfg.StampProvenance, "stampProvenance", false, "annotate objects with the time and tracer version of their last traced change, requires a mutating webhook")
+	flag.BoolVar(&cfg.StoreBinaryData, "storeBinaryData", false, "trace the content of configmap binaryData instead of the size and hash of each key")
+	flag.StringVar(&cfg.HeaderTemplate, "headerTemplate", listener.DefaultHeaderTemplate, "go template of the comment banner of stored files with the fields .GVK, .Name, .Namespace and .Timestamp, empty disables it")
+	flag.StringVar(&cfg.CommitMessageTemplate, "commitMessageTemplate", "", "go template of the first line of commit messages with the fields .User, .FieldManager, .Namespace, .Kind, .Name and .Operation, empty keeps the default messages")
+	flag.BoolVar(&cfg.RecordTouches, "recordTouches", false, "record admissions without changes as empty commits, this is high volume")
+	flag.Var((*stringSlice)(&cfg.TouchGVKs), "touchGVK", "gvk whose touches are recorded in the form of <group>-<version>.<kind>, e.g. apps-v1.Deployment, can be repeated, defaults to all")
+	flag.BoolVar(&cfg.KeepHistory, "keepHistory", false, "keep a snapshot of every version of an object before a change as "+listener.HistoryDir+"/<name>.<resourceVersion> next to the stored object")
+	flag.IntVar(&cfg.HistoryLimit, "historyLimit", listener.DefaultHistoryLimit, "snapshots kept per object by keepHistory, older ones are removed, 0 keeps all of them")
+	flag.IntVar(&cfg.FailureConfig.FailureThreshold, "gitFailureThreshold", 5, "consecutive git failures after which a warning event is emitted on the pod of the tracer, 0 disables it")
+	flag.DurationVar(&cfg.FailureConfig.SuspendOnFailure, "suspendGitOnFailure", 0, "skip git for this duration once gitFailureThreshold is reached, changes are only logged meanwhile, 0 disables it")
+	flag.Var((*stringSlice)(&cfg.Backends), "backend", "additional backend changes are stored in: sqlite, kafka, http, stdout or s3, can be repeated, git is only used with enableGitReview")
+	flag.StringVar(&cfg.DBPath, "dbPath", "/data/tracer.db", "path of the sqlite database of the sqlite backend")
+	flag.StringVar(&cfg.KafkaBrokers, "kafkaBrokers", "", "comma separated addresses of the kafka brokers of the kafka backend")
+	flag.StringVar(&cfg.KafkaConfig.Topic, "kafkaTopic", "", "topic changes are published to by the kafka backend")
+	flag.StringVar(&cfg.KafkaConfig.Key, "kafkaKey", backend.DefaultKafkaKey, "go template of the message key, changes with the same key keep their order")
+	flag.IntVar(&cfg.KafkaConfig.BufferSize, "kafkaBufferSize", backend.DefaultKafkaBufferSize, "changes buffered while the brokers are unavailable")
+	flag.DurationVar(&cfg.KafkaConfig.FlushEvery, "kafkaFlushEvery", backend.DefaultKafkaFlushEvery, "longest a change waits to be published together with others")
+	flag.IntVar(&cfg.KafkaConfig.BatchSize, "kafkaBatchSize", backend.DefaultKafkaBatchSize, "maximum number of changes published at once")
+	flag.StringVar(&cfg.KafkaConfig.SASLMechanism, "kafkaSASLMechanism", "", "sasl mechanism: plain, scram-sha-256 or scram-sha-512, credentials are read from the KAFKA_USERNAME/KAFKA_PASSWORD env")
+	flag.BoolVar(&cfg.KafkaConfig.TLS, "kafkaTLS", false, "connect to the kafka brokers with tls")
+	flag.StringVar(&cfg.HTTPConfig.URL, "httpBackendURL", "", "url the http backend posts changes to as json, a bearer token is read from the HTTP_BACKEND_TOKEN env")
+	flag.DurationVar(&cfg.HTTPConfig.Timeout, "httpBackendTimeout", backend.DefaultHTTPTimeout, "timeout of requests of the http backend")
+	flag.StringVar(&cfg.S3Config.Bucket, "s3Bucket", "", "bucket the s3 backend archives every version of an object to")
+	flag.StringVar(&cfg.S3Config.Prefix, "s3Prefix", "", "key prefix of the archived objects")
+	flag.StringVar(&cfg.S3Config.Region, "s3Region", "", "region of the bucket")
+	flag.StringVar(&cfg.S3Config.Endpoint, "s3Endpoint", backend.DefaultS3Endpoint, "url of the object storage, e.g. http://minio:9000, credentials are read from the standard AWS env, credentials file or IAM role")
+	flag.StringVar(&cfg.DeadLetterDir, "deadLetterDir", "", "directory keeping changes neither git nor any backend could store, replay them with replay-deadletter, empty disables it")
+	flag.IntVar(&cfg.DeadLetterMaxRecords, "deadLetterMaxRecords", deadletter.DefaultMaxRecords, "maximum number of changes kept in deadLetterDir")
+	flag.BoolVar(&cfg.Dedup, "dedup", false, "skip commits of objects going back to one of their last two committed states, e.g. when controllers fight over a field")
+	flag.IntVar(&cfg.DedupCacheSize, "dedupCacheSize", listener.DefaultDedupCacheSize, "number of objects whose committed states are remembered by dedup")
+	flag.DurationVar(&cfg.DedupTTL, "dedupTTL", listener.DefaultDedupTTL, "duration committed states are remembered by dedup")
+	flag.StringVar(&cfg.PerObjectRate, "perObjectRate", "", "maximum rate of commits of a single object, e.g. 2, 10/m or 100/h, changes above it are logged but not committed, unlimited if empty")
+	flag.IntVar(&cfg.PerObjectBurst, "perObjectBurst", listener.DefaultRateLimitBurst, "number of commits of a single object allowed at once above perObjectRate")
+	flag.StringVar(&cfg.ObservedIndexPath, "observedIndexPath", "", "json file outside of the git repository recording when every object was last admitted, including admissions without changes, empty disables it")
+	flag.StringVar(&cfg.GitURL, "gitURL", "", "url of git repository")
+	flag.StringVar(&cfg.GitPath, "gitPath", "", "local path of git repository")
+	flag.StringVar(&cfg.SubPath, "subPath", "", "relative path in git repository")
+	flag.StringVar(&cfg.Branch, "branch", defaultBranchName, "git branch, defaults to KUBERNETES_SERVICE_HOST in cluster and to "+defaultBranch+" out of cluster")
+	flag.StringVar(&cfg.BranchStrategy, "branchStrategy", listener.BranchSingle, "branches changes are committed to: single (branch), per-namespace (<branch>-<namespace>) or per-gvk (<branch>-<gvk>)")
+	flag.StringVar(&cfg.ClusterName, "clusterName", defaultBranchName, "name of the cluster used to look up the branch in the branch mapping and as directory with groupByCluster, defaults like branch")
+	flag.BoolVar(&cfg.GroupByCluster, "groupByCluster", false, "store objects under <subPath>/<clusterName> so that many clusters can share a branch")
+	flag.StringVar(&cfg.BranchMappingFile, "branchMappingFile", "", "yaml file mapping cluster names or service hosts to branches, branch is used when no entry matches")
+	flag.StringVar(&cfg.BranchMappingConfigMap, "branchMappingConfigMap", "", "configmap in the form of namespace/name holding the branch mapping under the key "+tracer.BranchMappingKey)
+	flag.BoolVar(&cfg.AutoRecoverRepo, "autoRecoverRepo", false, "reset a dirty or corrupted git working tree to the remote branch, uncommitted changes are discarded, commits missing on the remote are kept")
+	flag.BoolVar(&cfg.AutoGC, "autoGC", false, "compact the git repository on start and every gcInterval")
+	flag.DurationVar(&cfg.GCInterval, "gcInterval", 6*time.Hour, "interval of the repository compaction enabled by autoGC")
+	flag.DurationVar(&cfg.CommitInterval, "commitInterval", 0, "commit and push the changes of every interval at once instead of every change on its own, zero disables batching")
+	flag.BoolVar(&cfg.InitialSync, "initialSync", false, "store the existing objects of the traceGVK and informerKind kinds which are not stored yet before serving, requires enableGitReview")
+	flag.Var((*stringSlice)(&cfg.InformerKinds), "informerKind", "kind traced from watch events instead of admission requests in the form of Kind.version.group, e.g. Widget.v1.example.com, changes are attributed to "+listener.InformerActor+", can be repeated")
+	flag.BoolVar(&cfg.DetectDrift, "detectDrift", false, "compare admitted objects with the version stored in git and report drift with a metric and an event")
+	flag.Var((*stringSlice)(&cfg.DriftPaths), "driftPath", "path compared by detectDrift, e.g. spec.replicas, can be repeated, defaults to spec")
+	flag.IntVar(&cfg.MaxConcurrentHandlers, "maxConcurrentHandlers", 0, "maximum number of admission requests traced at once, 0 means no limit")
+	flag.DurationVar(&cfg.HandlerWait, "handlerWait", listener.DefaultHandlerWait, "time a request waits for a free handler before it is admitted without being traced")
+	flag.StringVar(&cfg.CredentialProvider, "credentialProvider", "static", "source of git credentials: static (env, see gitAuthMethod), vault or secret (gitCredentialsSecret, read before every git operation)")
+	flag.StringVar(&cfg.GitCredentialsSecret, "gitCredentialsSecret", "", "secret in the form of namespace/name holding a token under the key token or username and password, read before every clone, fetch and push of the secret credential provider")
+	flag.DurationVar(&cfg.GitOpTimeout, "gitOpTimeout", 0, "timeout of the fetches and pushes of a change, keep it below the webhook timeout, zero disables it")
+	flag.DurationVar(&cfg.GitCheckInterval, "gitCheckInterval", 30*time.Second, "interval of the checks of the git remote /readyz reports with enableGitReview, zero disables them")
+	flag.Var((*stringSlice)(&cfg.GitMirrors), "gitMirror", "additional remote in the form of name=url the branches are mirrored to after pushing to gitURL, with the same credentials, can be repeated")
+	flag.IntVar(&cfg.PushOptions.Backoff.Steps, "gitPushAttempts", git.DefaultPushOptions.Backoff.Steps, "attempts to push to the remote, local commits are rebased on the remote when it has advanced")
+	flag.StringVar(&cfg.GitAuthMethod, "gitAuthMethod", "basic", "git auth of the static credential provider: basic (GIT_USER_NAME/GIT_PASSWORD env), ssh (GIT_SSH_KEY_PATH/GIT_SSH_KEY_PASSPHRASE env) or token (GIT_TOKEN env)")
+	flag.StringVar(&cfg.VaultConfig.Address, "vaultAddress", "", "address of vault, e.g. https://vault:8200")
+	flag.StringVar(&cfg.VaultConfig.Role, "vaultRole", "", "vault role bound to the service account of the tracer")
+	flag.StringVar(&cfg.VaultConfig.SecretPath, "vaultSecretPath", "", "vault path of the git credentials, e.g. secret/data/git")
+	flag.StringVar(&cfg.VaultConfig.AuthPath, "vaultAuthPath", vault.DefaultAuthPath, "mount path of the vault kubernetes auth method")
+	flag.StringVar(&cfg.ReviewProvider, "reviewProvider", "", "open pull requests with the given provider instead of pushing to the branch directly: gitea, github or gitlab")
+	flag.StringVar(&cfg.ReviewAPIURL, "reviewAPIURL", "", "base url of the review provider, e.g. https://gitea.example.com, defaults to github.com or gitlab.com for those providers")
+	flag.StringVar(&cfg.ReviewBranch, "reviewBranch", "", "head branch of pull requests, defaults to k8s-resource-tracer/<branch>")
+	flag.BoolVar(&cfg.ReviewByAnnotation, "reviewByAnnotation", false, "open pull requests only for objects annotated with "+listener.RequiresApprovalAnnotation+"=true, push the others directly")
+	flag.StringVar(&cfg.AuthorMappingFile, "authorMappingFile", "", "yaml file mapping kubernetes users to commit authors")
+	flag.StringVar(&cfg.Identity.EmailDomain, "gitAuthorEmailDomain", git.DefaultAuthorEmailDomain, "domain of the emails made up for authors without one, e.g. company.com gives <user>@company.com")
+	flag.StringVar(&cfg.Identity.Committer.Name, "gitCommitterName", "", "committer of the commits, e.g. the name of the machine pushing them, empty means the author")
+	flag.StringVar(&cfg.Identity.Committer.Email, "gitCommitterEmail", "", "email of the committer, made up from gitAuthorEmailDomain if empty")
+	flag.StringVar(&cfg.AuthorMappingConfigMap, "authorMappingConfigMap", "", "configmap in the form of namespace/name holding the author mapping under the key "+tracer.AuthorMappingKey)
+	flag.BoolVar(&cfg.SignCommits, "signCommits", false, "sign commits with the gpg key of signKeyFile or signKeySecret")
+	flag.StringVar(&cfg.SignKeyFile, "signKeyFile", "", "armored gpg private key signing the commits")
+	flag.StringVar(&cfg.SignKeyPassphraseFile, "signKeyPassphraseFile", "", "file holding the passphrase of the key of signKeyFile")
+	flag.StringVar(&cfg.SignKeySecret, "signKeySecret", "", "secret in the form of namespace/name holding the armored gpg private key under the key "+tracer.SignKeySecretKey+" and its passphrase under "+tracer.SignPassphraseSecretKey)
+	flag.BoolVar(&cfg.GroupByApp, "groupByApp", false, "store objects under the app folder resolved from their owner chain")
+	flag.StringVar(&cfg.AppLabel, "appLabel", "app.kubernetes.io/name", "label used to resolve the app of an object when groupByApp is enabled")
+
+	flag.BoolVar(&cfg.EnableLeaderElection, "enableLeaderElection", false, "elect a leader among the replicas, only the leader writes to git")
+	flag.StringVar(&cfg.LeaderElectionID, "leaderElectionID", "k8s-resource-tracer", "name of the lease used for leader election")
+	flag.StringVar(&cfg.LeaderElectionNamespace, "leaderElectionNamespace", "", "namespace of the lease, defaults to the namespace of the pod")
+	flag.DurationVar(&cfg.LeaseDuration, "leaseDuration", 15*time.Second, "duration non-leader replicas wait before acquiring the lease")
+	flag.DurationVar(&cfg.RenewDeadline, "renewDeadline", 10*time.Second, "duration the leader retries renewing the lease before giving it up")
+	flag.DurationVar(&cfg.RetryPeriod, "retryPeriod", 2*time.Second, "duration between attempts to acquire or renew the lease")
+
+	opts.BindFlags(flag.CommandLine)
+	flag.Parse()
+
+	// the logger is built once flags are parsed, --debug and the zap flags have no effect otherwise
 	var logLevel zapcore.Level
 	if debug {
 		logLevel = zapcore.DebugLevel
 	} else {
 		logLevel = zapcore.InfoLevel
-
-	}
-
-	opts := zap.Options{
-		Development: true,
 	}
 
 	logger := zap.New(zap.UseFlagOptions(&opts), zap.Level(logLevel))
 	log.SetLogger(logger)
 
-	k8sHost, ok := os.LookupEnv("KUBERNETES_SERVICE_HOST")
-	if !ok {
-		logger.Error(fmt.Errorf("internal error"), "failed to get env KUBERNETES_SERVICE_HOST")
-		os.Exit(1)
+	if !inCluster {
+		logger.Info("KUBERNETES_SERVICE_HOST is not set, running out of cluster", "branch", cfg.Branch)
 	}
 
-	flag.BoolVar(&debug, "debug", false, "Enable debug logging")
-	flag.BoolVar(&enableGitReview, "enableGitReview", false, "Enable git review")
-	flag.StringVar(&gitURL, "gitURL", "", "url of git repository")
-	flag.StringVar(&gitPath, "gitPath", "", "local path of git repository")
-	flag.StringVar(&subPath, "subPath", "", "relative path in git repository")
-	flag.StringVar(&branch, "branch", k8sHost, "git branch")
+	if printConfig {
+		if err := printEffectiveConfig(); err != nil {
+			logger.Error(err, "failed to print config")
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
 
-	opts.BindFlags(flag.CommandLine)
-	flag.Parse()
+	cfg.Logger = logger
+	cfg.Version = version
 
-	lw := &listener.ListenerWebhook{
-		Logger:          logger,
-		EnableGitReview: enableGitReview,
+	// ctx is cancelled on SIGTERM, background flushers get to write their last state before Run returns
+	if err := tracer.Run(ctrl.SetupSignalHandler(), cfg); err != nil {
+		logger.Error(err, "failed to run k8s resource tracer")
+		os.Exit(1)
 	}
+}
 
-	if enableGitReview {
-		userName, _ := os.LookupEnv("GIT_USER_NAME")
-		pwd, _ := os.LookupEnv("GIT_PASSWORD")
+// printEffectiveConfig prints all flags including defaults and the environment variables in use as yaml
+func printEffectiveConfig() error {
+	flags := map[string]string{}
+	flag.VisitAll(func(f *flag.Flag) {
+		flags[f.Name] = f.Value.String()
+	})
 
-		auth := &http.BasicAuth{
-			Username: userName,
-			Password: pwd,
+	envs := map[string]string{}
+	for _, name := range configEnvs {
+		v, ok := os.LookupEnv(name)
+		if !ok {
+			continue
 		}
-
-		lw.GitConfig = listener.GitConfig{
-			GitPath:   gitPath,
-			SubPath:   subPath,
-			GitBranch: branch,
-			GitAuth:   auth,
-		}
-
-		if err := git.Clone(gitURL, gitPath, auth); err != nil {
-			logger.Error(err, "failed to clone git repo", "url", gitURL, "path", gitPath)
-			os.Exit(1)
+		if secretEnvs[name] && v != "" {
+			v = "******"
 		}
+		envs[name] = v
+	}
 
-		if err := git.Checkout(gitPath, branch, logger); err != nil {
-			logger.Error(err, "failed to checkout to git branch", "path", gitPath, "branch", branch)
-			os.Exit(1)
-		}
+	out, err := yaml.Marshal(map[string]interface{}{"flags": flags, "env": envs})
+	if err != nil {
+		return err
 	}
 
-	webhookServer := webhook.NewServer(webhook.Options{})
-	webhookServer.Register("/listen", &admission.Webhook{Handler: lw, LogConstructor: func(base logr.Logger, req *admission.Request) logr.Logger {
-		return logger
-	}})
+	fmt.Print(string(out))
+	return nil
+}
 
-	webhookServer.Register("/healthz", &healthz.CheckHandler{Checker: healthz.Ping})
-	webhookServer.Register("/readyz", &healthz.CheckHandler{Checker: healthz.Ping})
+// stringSlice is a flag which can be repeated
+type stringSlice []string
 
-	logger.Info("starting k8s resource tracer", "port", 9443)
-	if err := webhookServer.Start(context.TODO()); err != nil {
-		logger.Error(err, "failed to startk8s resource tracer")
-		os.Exit(1)
-	}
+func (s *stringSlice) String() string {
+	return strings.Join(*s, ",")
+}
+
+func (s *stringSlice) Set(v string) error {
+	*s = append(*s, v)
+	return nil
 }
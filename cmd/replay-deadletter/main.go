@@ -0,0 +1,144 @@
+/*
+Copyright 2024.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// replay-deadletter stores the changes of a dead letter directory again, the records which succeed are removed
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/go-git/go-git/v5/plumbing/transport/http"
+	"github.com/go-logr/logr"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+
+	"github.com/reborn1867/k8s-resource-tracer/pkg/backend"
+	"github.com/reborn1867/k8s-resource-tracer/pkg/deadletter"
+	"github.com/reborn1867/k8s-resource-tracer/pkg/git"
+)
+
+func main() {
+	var deadLetterDir string
+	var gitPath string
+	var push bool
+	var dbPath string
+	var authorMappingFile string
+	var identity git.Identity
+
+	flag.StringVar(&deadLetterDir, "deadLetterDir", "", "dead letter directory of the tracer")
+	flag.StringVar(&gitPath, "gitPath", "", "local clone the git changes are committed to, empty skips them")
+	flag.BoolVar(&push, "push", true, "push the replayed commits, credentials are read from the GIT_USER_NAME/GIT_PASSWORD env")
+	flag.StringVar(&dbPath, "dbPath", "", "sqlite database the changes are stored in, empty skips it")
+	flag.StringVar(&authorMappingFile, "authorMappingFile", "", "yaml file mapping kubernetes users to commit authors, the same as the tracer's")
+	flag.StringVar(&identity.EmailDomain, "gitAuthorEmailDomain", git.DefaultAuthorEmailDomain, "domain of the emails made up for authors without one, the same as the tracer's")
+	flag.StringVar(&identity.Committer.Name, "gitCommitterName", "", "committer of the replayed commits, empty means the author")
+	flag.StringVar(&identity.Committer.Email, "gitCommitterEmail", "", "email of the committer, made up from gitAuthorEmailDomain if empty")
+
+	opts := zap.Options{Development: true}
+	opts.BindFlags(flag.CommandLine)
+	flag.Parse()
+
+	logger := zap.New(zap.UseFlagOptions(&opts))
+
+	if deadLetterDir == "" || (gitPath == "" && dbPath == "") {
+		logger.Error(fmt.Errorf("deadLetterDir and one of gitPath or dbPath are required"), "invalid flags")
+		os.Exit(1)
+	}
+
+	store, err := deadletter.NewStore(deadLetterDir, 0)
+	if err != nil {
+		logger.Error(err, "failed to open dead letter store")
+		os.Exit(1)
+	}
+
+	if authorMappingFile != "" {
+		if identity.Authors, err = git.LoadAuthorMapping(authorMappingFile); err != nil {
+			logger.Error(err, "failed to load author mapping", "path", authorMappingFile)
+			os.Exit(1)
+		}
+	}
+
+	var db *backend.SQLiteBackend
+	if dbPath != "" {
+		if db, err = backend.NewSQLiteBackend(dbPath); err != nil {
+			logger.Error(err, "failed to open sqlite database", "path", dbPath)
+			os.Exit(1)
+		}
+		defer db.Close()
+	}
+
+	names, err := store.List()
+	if err != nil {
+		logger.Error(err, "failed to list dead letter records")
+		os.Exit(1)
+	}
+
+	replayed, failed := 0, 0
+	for _, name := range names {
+		rec, err := store.Get(name)
+		if err != nil {
+			logger.Error(err, "failed to read record", "record", name)
+			failed++
+			// the record may hold a change of any object, later records must not overtake it
+			break
+		}
+
+		if err := replay(rec, gitPath, identity, db, logger); err != nil {
+			logger.Error(err, "failed to replay record", "record", name)
+			failed++
+			// later changes of the same object must not overtake this one
+			break
+		}
+
+		if err := store.Remove(name); err != nil {
+			logger.Error(err, "failed to remove replayed record", "record", name)
+		}
+		replayed++
+	}
+
+	if gitPath != "" && push && replayed > 0 {
+		userName, _ := os.LookupEnv("GIT_USER_NAME")
+		pwd, _ := os.LookupEnv("GIT_PASSWORD")
+		if err := git.PushToRemote(context.Background(), gitPath, &http.BasicAuth{Username: userName, Password: pwd}, git.DefaultPushOptions, logger); err != nil {
+			logger.Error(err, "failed to push replayed commits", "path", gitPath)
+			os.Exit(1)
+		}
+	}
+
+	logger.Info("replayed dead letter records", "replayed", replayed, "failed", failed, "remaining", len(names)-replayed)
+	if failed > 0 {
+		os.Exit(1)
+	}
+}
+
+func replay(rec deadletter.Record, gitPath string, identity git.Identity, db *backend.SQLiteBackend, logger logr.Logger) error {
+	if gitPath != "" && rec.Git != nil {
+		g := rec.Git
+		if err := git.CommitChange(gitPath, g.Subpath, g.Operation, g.User, g.FieldManager, g.Subject, g.Data, g.Trailers, identity, logger); err != nil {
+			return err
+		}
+	}
+
+	if db != nil {
+		if err := db.Store(context.TODO(), rec.Change); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,35 @@
+package backend
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+)
+
+// Change is a traced change of an object
+type Change struct {
+	GVK          string          `json:"gvk"`
+	Namespace    string          `json:"namespace,omitempty"`
+	Name         string          `json:"name"`
+	User         string          `json:"user"`
+	FieldManager string          `json:"fieldManager"`
+	Operation    string          `json:"operation"`
+	Timestamp    time.Time       `json:"timestamp"`
+	OldObject    json.RawMessage `json:"oldObject,omitempty"`
+	NewObject    json.RawMessage `json:"newObject,omitempty"`
+	// Diff is the uncolored diff of the whole object
+	Diff string `json:"diff"`
+	// Sections are the uncolored diffs of the changed sections, e.g. spec and status
+	Sections map[string]string `json:"sections,omitempty"`
+	// Groups and Extra are the groups and extra claims of User, e.g. the groups of an oidc token
+	Groups []string            `json:"groups,omitempty"`
+	Extra  map[string][]string `json:"extra,omitempty"`
+}
+
+// DefaultDrainTimeout bounds the time buffering backends take to deliver what is left in their buffer once stopped
+const DefaultDrainTimeout = 10 * time.Second
+
+// Backend stores traced changes next to or instead of git
+type Backend interface {
+	Store(ctx context.Context, c Change) error
+}
@@ -0,0 +1,70 @@
+package backend
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const DefaultHTTPTimeout = 5 * time.Second
+
+type HTTPConfig struct {
+	URL string
+	// Token is sent as bearer token, empty sends none
+	Token   string
+	Timeout time.Duration
+}
+
+// HTTPBackend posts every change as json to an endpoint, any status other than 2xx fails the change
+type HTTPBackend struct {
+	HTTPConfig
+	client *http.Client
+}
+
+func NewHTTPBackend(cfg HTTPConfig) (*HTTPBackend, error) {
+	if cfg.URL == "" {
+		return nil, fmt.Errorf("url of the http backend is required")
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = DefaultHTTPTimeout
+	}
+
+	return &HTTPBackend{
+		HTTPConfig: cfg,
+		client:     &http.Client{Timeout: cfg.Timeout},
+	}, nil
+}
+
+func (b *HTTPBackend) Store(ctx context.Context, c Change) error {
+	data, err := json.Marshal(c)
+	if err != nil {
+		return fmt.Errorf("failed to marshal change, err: %s", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, b.URL, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if b.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+b.Token)
+	}
+
+	resp, err := b.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to post change, url: %s, err: %s", b.URL, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("unexpected status %d from %s: %s", resp.StatusCode, b.URL, strings.TrimSpace(string(body)))
+	}
+
+	return nil
+}
@@ -0,0 +1,251 @@
+package backend
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"text/template"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/segmentio/kafka-go"
+	"github.com/segmentio/kafka-go/sasl"
+	"github.com/segmentio/kafka-go/sasl/plain"
+	"github.com/segmentio/kafka-go/sasl/scram"
+)
+
+const (
+	// DefaultKafkaKey keeps the changes of an object in order on a single partition
+	DefaultKafkaKey        = "{{ if .Namespace }}{{ .Namespace }}/{{ end }}{{ .Name }}"
+	DefaultKafkaBufferSize = 1000
+	DefaultKafkaFlushEvery = time.Second
+	DefaultKafkaBatchSize  = 100
+
+	kafkaRetryMin = time.Second
+	kafkaRetryMax = time.Minute
+)
+
+type KafkaConfig struct {
+	Brokers []string
+	Topic   string
+	// Key is a template over Change rendering the message key
+	Key string
+	// BufferSize bounds the changes held in memory while the brokers are unavailable
+	BufferSize int
+	// FlushEvery is the longest a queued change waits to be published with others, at most BatchSize at once
+	FlushEvery time.Duration
+	BatchSize  int
+	// SASLMechanism is one of plain, scram-sha-256 or scram-sha-512, empty disables SASL
+	SASLMechanism string
+	Username      string
+	Password      string
+	TLS           bool
+}
+
+// kafkaWriter is the part of kafka.Writer used by the backend
+type kafkaWriter interface {
+	WriteMessages(ctx context.Context, msgs ...kafka.Message) error
+	Close() error
+}
+
+// KafkaBackend publishes every change as a json message. Changes are buffered and retried with backoff while the
+// brokers are unavailable, changes arriving while the buffer is full are rejected.
+type KafkaBackend struct {
+	writer       kafkaWriter
+	key          *template.Template
+	buffer       chan kafka.Message
+	flushEvery   time.Duration
+	batchSize    int
+	drainTimeout time.Duration
+	logger       logr.Logger
+}
+
+func NewKafkaBackend(cfg KafkaConfig, logger logr.Logger) (*KafkaBackend, error) {
+	if len(cfg.Brokers) == 0 || cfg.Topic == "" {
+		return nil, fmt.Errorf("kafka brokers and topic are required")
+	}
+
+	transport := &kafka.Transport{}
+	if cfg.TLS {
+		transport.TLS = &tls.Config{MinVersion: tls.VersionTLS12}
+	}
+	if cfg.SASLMechanism != "" {
+		mechanism, err := saslMechanism(cfg.SASLMechanism, cfg.Username, cfg.Password)
+		if err != nil {
+			return nil, err
+		}
+		transport.SASL = mechanism
+	}
+
+	writer := &kafka.Writer{
+		Addr:         kafka.TCP(cfg.Brokers...),
+		Topic:        cfg.Topic,
+		Balancer:     &kafka.Hash{},
+		RequiredAcks: kafka.RequireAll,
+		Transport:    transport,
+		// batches are collected by Run, the writer must not wait for more
+		BatchTimeout: time.Millisecond,
+	}
+
+	return newKafkaBackend(writer, cfg, logger)
+}
+
+func newKafkaBackend(writer kafkaWriter, cfg KafkaConfig, logger logr.Logger) (*KafkaBackend, error) {
+	if cfg.Key == "" {
+		cfg.Key = DefaultKafkaKey
+	}
+	if cfg.BufferSize <= 0 {
+		cfg.BufferSize = DefaultKafkaBufferSize
+	}
+	if cfg.FlushEvery <= 0 {
+		cfg.FlushEvery = DefaultKafkaFlushEvery
+	}
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = DefaultKafkaBatchSize
+	}
+
+	key, err := template.New("key").Parse(cfg.Key)
+	if err != nil {
+		return nil, fmt.Errorf("invalid kafka key template: %s", err)
+	}
+
+	return &KafkaBackend{
+		writer:       writer,
+		key:          key,
+		buffer:       make(chan kafka.Message, cfg.BufferSize),
+		flushEvery:   cfg.FlushEvery,
+		batchSize:    cfg.BatchSize,
+		drainTimeout: DefaultDrainTimeout,
+		logger:       logger.WithName("kafka"),
+	}, nil
+}
+
+func saslMechanism(name, username, password string) (sasl.Mechanism, error) {
+	switch name {
+	case "plain":
+		return plain.Mechanism{Username: username, Password: password}, nil
+	case "scram-sha-256":
+		return scram.Mechanism(scram.SHA256, username, password)
+	case "scram-sha-512":
+		return scram.Mechanism(scram.SHA512, username, password)
+	default:
+		return nil, fmt.Errorf("unknown sasl mechanism %s", name)
+	}
+}
+
+// Store queues the change, it is published by Run
+func (b *KafkaBackend) Store(ctx context.Context, c Change) error {
+	key := bytes.Buffer{}
+	if err := b.key.Execute(&key, c); err != nil {
+		return fmt.Errorf("failed to render kafka key: %s", err)
+	}
+
+	value, err := json.Marshal(c)
+	if err != nil {
+		return err
+	}
+
+	select {
+	case b.buffer <- kafka.Message{Key: key.Bytes(), Value: value, Time: c.Timestamp}:
+		return nil
+	default:
+		return fmt.Errorf("kafka buffer is full, dropping change of %s %s/%s", c.GVK, c.Namespace, c.Name)
+	}
+}
+
+// Run publishes the queued changes in order until ctx is done. Changes are collected for FlushEvery or until
+// BatchSize is reached and published at once, a batch failing to be published is retried with backoff before the
+// next one is collected. Once ctx is done the changes left are published for at most DefaultDrainTimeout.
+func (b *KafkaBackend) Run(ctx context.Context) {
+	defer b.writer.Close()
+
+	for {
+		var msg kafka.Message
+		select {
+		case <-ctx.Done():
+			b.drain(nil)
+			return
+		case msg = <-b.buffer:
+		}
+
+		batch, ok := b.collect(ctx, msg)
+		if !ok || !b.publish(ctx, batch) {
+			b.drain(batch)
+			return
+		}
+	}
+}
+
+// publish publishes batch, retrying with backoff, it reports false if ctx is done before the batch is published
+func (b *KafkaBackend) publish(ctx context.Context, batch []kafka.Message) bool {
+	retry := kafkaRetryMin
+	for {
+		err := b.writer.WriteMessages(ctx, batch...)
+		if err == nil {
+			return true
+		}
+		if ctx.Err() != nil {
+			return false
+		}
+
+		b.logger.Error(err, "failed to publish changes, retrying", "changes", len(batch), "retryIn", retry, "buffered", len(b.buffer))
+		select {
+		case <-ctx.Done():
+			return false
+		case <-time.After(retry):
+		}
+		if retry *= 2; retry > kafkaRetryMax {
+			retry = kafkaRetryMax
+		}
+	}
+}
+
+// drain publishes batch and the changes left in the buffer in batches once Run is stopped
+func (b *KafkaBackend) drain(batch []kafka.Message) {
+	ctx, cancel := context.WithTimeout(context.Background(), b.drainTimeout)
+	defer cancel()
+
+	for {
+	fill:
+		for len(batch) < b.batchSize {
+			select {
+			case msg := <-b.buffer:
+				batch = append(batch, msg)
+			default:
+				break fill
+			}
+		}
+		if len(batch) == 0 {
+			return
+		}
+
+		if !b.publish(ctx, batch) {
+			b.logger.Info("failed to publish changes before stopping, dropping them", "changes", len(batch)+len(b.buffer))
+			return
+		}
+		batch = nil
+	}
+}
+
+// collect adds queued changes to the batch starting with first until the batch is full or FlushEvery has passed,
+// it reports false with the batch collected so far if ctx is done
+func (b *KafkaBackend) collect(ctx context.Context, first kafka.Message) ([]kafka.Message, bool) {
+	batch := []kafka.Message{first}
+	timer := time.NewTimer(b.flushEvery)
+	defer timer.Stop()
+
+	for len(batch) < b.batchSize {
+		select {
+		case <-ctx.Done():
+			return batch, false
+		case msg := <-b.buffer:
+			batch = append(batch, msg)
+		case <-timer.C:
+			return batch, true
+		}
+	}
+
+	return batch, true
+}
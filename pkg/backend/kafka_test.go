@@ -0,0 +1,186 @@
+package backend
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/segmentio/kafka-go"
+)
+
+// mockWriter records the published messages, the first failures calls fail
+type mockWriter struct {
+	mu        sync.Mutex
+	published []kafka.Message
+	batches   int
+	failures  int
+	closed    bool
+}
+
+func (w *mockWriter) WriteMessages(ctx context.Context, msgs ...kafka.Message) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if w.failures > 0 {
+		w.failures--
+		return errors.New("broker not available")
+	}
+	w.published = append(w.published, msgs...)
+	w.batches++
+	return nil
+}
+
+func (w *mockWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.closed = true
+	return nil
+}
+
+func (w *mockWriter) keys() []string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	keys := []string{}
+	for _, m := range w.published {
+		keys = append(keys, string(m.Key))
+	}
+	return keys
+}
+
+func newTestKafka(t *testing.T, w *mockWriter, cfg KafkaConfig) *KafkaBackend {
+	t.Helper()
+	b, err := newKafkaBackend(w, cfg, logr.Discard())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+func TestKafkaStoreRendersKey(t *testing.T) {
+	b := newTestKafka(t, &mockWriter{}, KafkaConfig{})
+	for _, c := range []Change{{Namespace: "default", Name: "a"}, {Name: "node"}} {
+		if err := b.Store(context.Background(), c); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, want := range []string{"default/a", "node"} {
+		msg := <-b.buffer
+		if got := string(msg.Key); got != want {
+			t.Errorf("expected key %s, got %s", want, got)
+		}
+		c := Change{}
+		if err := json.Unmarshal(msg.Value, &c); err != nil {
+			t.Errorf("expected the change as json, got %s: %s", msg.Value, err)
+		}
+	}
+}
+
+func TestKafkaStoreRejectsWhenBufferIsFull(t *testing.T) {
+	b := newTestKafka(t, &mockWriter{}, KafkaConfig{BufferSize: 1})
+	if err := b.Store(context.Background(), Change{Name: "a"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.Store(context.Background(), Change{Name: "b"}); err == nil {
+		t.Error("expected a full buffer to reject the change")
+	}
+}
+
+func TestKafkaRunPublishesBatchesInOrder(t *testing.T) {
+	w := &mockWriter{}
+	b := newTestKafka(t, w, KafkaConfig{BatchSize: 2, FlushEvery: 10 * time.Millisecond})
+	for _, name := range []string{"a", "b", "c"} {
+		if err := b.Store(context.Background(), Change{Name: name}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		b.Run(ctx)
+		close(done)
+	}()
+
+	waitFor(t, func() bool { return len(w.keys()) == 3 })
+	cancel()
+	<-done
+
+	if got := w.keys(); got[0] != "a" || got[1] != "b" || got[2] != "c" {
+		t.Errorf("expected changes in order, got %v", got)
+	}
+	if w.batches != 2 {
+		t.Errorf("expected 2 batches of at most 2 changes, got %d", w.batches)
+	}
+	if !w.closed {
+		t.Error("expected the writer to be closed")
+	}
+}
+
+func TestKafkaRunRetriesFailedBatch(t *testing.T) {
+	w := &mockWriter{failures: 1}
+	b := newTestKafka(t, w, KafkaConfig{FlushEvery: time.Millisecond})
+	if err := b.Store(context.Background(), Change{Name: "a"}); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go b.Run(ctx)
+
+	// the retry waits kafkaRetryMin
+	waitFor(t, func() bool { return len(w.keys()) == 1 })
+}
+
+func TestKafkaRunDrainsBufferOnStop(t *testing.T) {
+	w := &mockWriter{}
+	b := newTestKafka(t, w, KafkaConfig{BatchSize: 2})
+	for _, name := range []string{"a", "b", "c"} {
+		if err := b.Store(context.Background(), Change{Name: name}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	b.Run(ctx)
+
+	if got := w.keys(); len(got) != 3 {
+		t.Errorf("expected the buffered changes to be published on stop, got %v", got)
+	}
+}
+
+func TestKafkaDrainGivesUp(t *testing.T) {
+	w := &mockWriter{failures: 1000}
+	b := newTestKafka(t, w, KafkaConfig{})
+	b.drainTimeout = 10 * time.Millisecond
+	if err := b.Store(context.Background(), Change{Name: "a"}); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	start := time.Now()
+	b.Run(ctx)
+
+	if elapsed := time.Since(start); elapsed > kafkaRetryMin+time.Second {
+		t.Errorf("expected the drain to stop after its timeout, took %s", elapsed)
+	}
+}
+
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition not met in time")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
@@ -0,0 +1,155 @@
+package backend
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	DefaultS3Endpoint   = "https://s3.amazonaws.com"
+	DefaultS3BufferSize = 1000
+)
+
+type S3Config struct {
+	Bucket string
+	Prefix string
+	Region string
+	// Endpoint is the url of the object storage, e.g. http://minio:9000
+	Endpoint string
+	// BufferSize bounds the snapshots waiting to be uploaded
+	BufferSize int
+}
+
+// s3Object is a snapshot waiting to be uploaded
+type s3Object struct {
+	key  string
+	data []byte
+}
+
+// S3Backend archives every version of an object as yaml under <prefix>/<namespace>/<gvk>/<name>/<resourceVersion>.yaml.
+// Snapshots are uploaded in the background, failed uploads are logged and dropped so that admission is never
+// blocked. Credentials are read from the AWS env, the shared credentials file or the IAM role of the pod.
+type S3Backend struct {
+	S3Config
+	client       *minio.Client
+	buffer       chan s3Object
+	drainTimeout time.Duration
+	logger       logr.Logger
+}
+
+func NewS3Backend(cfg S3Config, logger logr.Logger) (*S3Backend, error) {
+	if cfg.Bucket == "" {
+		return nil, fmt.Errorf("bucket of the s3 backend is required")
+	}
+	if cfg.Endpoint == "" {
+		cfg.Endpoint = DefaultS3Endpoint
+	}
+	if cfg.BufferSize <= 0 {
+		cfg.BufferSize = DefaultS3BufferSize
+	}
+
+	endpoint, err := url.Parse(cfg.Endpoint)
+	if err != nil || endpoint.Host == "" {
+		return nil, fmt.Errorf("invalid s3 endpoint %s, expected a url like https://s3.amazonaws.com", cfg.Endpoint)
+	}
+
+	client, err := minio.New(endpoint.Host, &minio.Options{
+		Creds: credentials.NewChainCredentials([]credentials.Provider{
+			&credentials.EnvAWS{},
+			&credentials.FileAWSCredentials{},
+			&credentials.IAM{},
+		}),
+		Secure: endpoint.Scheme != "http",
+		Region: cfg.Region,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create s3 client, endpoint: %s, err: %s", cfg.Endpoint, err)
+	}
+
+	return &S3Backend{
+		S3Config:     cfg,
+		client:       client,
+		buffer:       make(chan s3Object, cfg.BufferSize),
+		drainTimeout: DefaultDrainTimeout,
+		logger:       logger.WithName("s3"),
+	}, nil
+}
+
+// Store queues the snapshot of the new object, it is uploaded by Run. Deletions leave no new version to archive.
+func (b *S3Backend) Store(ctx context.Context, c Change) error {
+	var obj struct {
+		Metadata struct {
+			ResourceVersion string `json:"resourceVersion"`
+		} `json:"metadata"`
+	}
+	if err := json.Unmarshal(c.NewObject, &obj); err != nil || obj.Metadata.ResourceVersion == "" {
+		b.logger.V(1).Info("no new version to archive", "gvk", c.GVK, "name", c.Name, "namespace", c.Namespace, "operation", c.Operation)
+		return nil
+	}
+
+	data, err := yaml.JSONToYAML(c.NewObject)
+	if err != nil {
+		return fmt.Errorf("failed to convert object to yaml, err: %s", err)
+	}
+
+	key := path.Join(b.Prefix, c.Namespace, c.GVK, c.Name, obj.Metadata.ResourceVersion+".yaml")
+	select {
+	case b.buffer <- s3Object{key: strings.TrimPrefix(key, "/"), data: data}:
+		return nil
+	default:
+		return fmt.Errorf("s3 buffer is full, dropping snapshot %s", key)
+	}
+}
+
+// Run uploads the queued snapshots until ctx is done, the snapshots left are uploaded for at most
+// DefaultDrainTimeout afterwards
+func (b *S3Backend) Run(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			b.drain()
+			return
+		case obj := <-b.buffer:
+			b.upload(ctx, obj)
+		}
+	}
+}
+
+// drain uploads the snapshots left in the buffer once Run is stopped
+func (b *S3Backend) drain() {
+	ctx, cancel := context.WithTimeout(context.Background(), b.drainTimeout)
+	defer cancel()
+
+	for {
+		select {
+		case obj := <-b.buffer:
+			if ctx.Err() != nil {
+				b.logger.Info("failed to upload snapshots before stopping, dropping them", "snapshots", len(b.buffer)+1)
+				return
+			}
+			b.upload(ctx, obj)
+		default:
+			return
+		}
+	}
+}
+
+func (b *S3Backend) upload(ctx context.Context, obj s3Object) {
+	if _, err := b.client.PutObject(ctx, b.Bucket, obj.key, bytes.NewReader(obj.data), int64(len(obj.data)),
+		minio.PutObjectOptions{ContentType: "application/yaml"}); err != nil {
+		b.logger.Error(err, "failed to upload snapshot", "bucket", b.Bucket, "key", obj.key)
+		return
+	}
+	b.logger.V(1).Info("uploaded snapshot", "bucket", b.Bucket, "key", obj.key)
+}
@@ -0,0 +1,117 @@
+package backend
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	_ "github.com/ncruces/go-sqlite3/driver"
+	_ "github.com/ncruces/go-sqlite3/embed"
+)
+
+// migrations are applied in order, the index of the last applied one is kept in schema_version
+var migrations = []string{
+	`CREATE TABLE changes (
+		id            INTEGER PRIMARY KEY AUTOINCREMENT,
+		gvk           TEXT NOT NULL,
+		namespace     TEXT NOT NULL,
+		name          TEXT NOT NULL,
+		actor         TEXT NOT NULL,
+		field_manager TEXT NOT NULL,
+		operation     TEXT NOT NULL,
+		timestamp     DATETIME NOT NULL,
+		old_object    TEXT,
+		new_object    TEXT,
+		diff          TEXT NOT NULL
+	)`,
+	`CREATE INDEX changes_object ON changes (gvk, namespace, name, timestamp)`,
+	// json of the groups and extra claims of the actor
+	`ALTER TABLE changes ADD COLUMN actor_groups TEXT`,
+	`ALTER TABLE changes ADD COLUMN actor_extra TEXT`,
+}
+
+// SQLiteBackend stores every change as a row of the changes table of a sqlite database
+type SQLiteBackend struct {
+	db *sql.DB
+}
+
+// NewSQLiteBackend opens or creates the database at path and migrates it to the latest schema
+func NewSQLiteBackend(path string) (*SQLiteBackend, error) {
+	db, err := sql.Open("sqlite3", fmt.Sprintf("file:%s?_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)", path))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database, path: %s, err: %s", path, err)
+	}
+	// sqlite allows a single writer
+	db.SetMaxOpenConns(1)
+
+	if err := migrate(db); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to migrate database, path: %s, err: %s", path, err)
+	}
+
+	return &SQLiteBackend{db: db}, nil
+}
+
+func migrate(db *sql.DB) error {
+	if _, err := db.Exec(`CREATE TABLE IF NOT EXISTS schema_version (version INTEGER NOT NULL)`); err != nil {
+		return err
+	}
+
+	version := 0
+	if err := db.QueryRow(`SELECT COALESCE(MAX(version), 0) FROM schema_version`).Scan(&version); err != nil {
+		return err
+	}
+
+	for i := version; i < len(migrations); i++ {
+		tx, err := db.Begin()
+		if err != nil {
+			return err
+		}
+		if _, err := tx.Exec(migrations[i]); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("migration %d: %s", i+1, err)
+		}
+		if _, err := tx.Exec(`INSERT INTO schema_version (version) VALUES (?)`, i+1); err != nil {
+			tx.Rollback()
+			return err
+		}
+		if err := tx.Commit(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (b *SQLiteBackend) Store(ctx context.Context, c Change) error {
+	var groups, extra []byte
+	if len(c.Groups) > 0 {
+		groups, _ = json.Marshal(c.Groups)
+	}
+	if len(c.Extra) > 0 {
+		extra, _ = json.Marshal(c.Extra)
+	}
+
+	_, err := b.db.ExecContext(ctx, `INSERT INTO changes
+		(gvk, namespace, name, actor, actor_groups, actor_extra, field_manager, operation, timestamp, old_object, new_object, diff)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		c.GVK, c.Namespace, c.Name, c.User, nullable(groups), nullable(extra), c.FieldManager, c.Operation, c.Timestamp.UTC(),
+		nullable(c.OldObject), nullable(c.NewObject), c.Diff)
+	if err != nil {
+		return fmt.Errorf("failed to insert change of %s %s/%s: %s", c.GVK, c.Namespace, c.Name, err)
+	}
+
+	return nil
+}
+
+func (b *SQLiteBackend) Close() error {
+	return b.db.Close()
+}
+
+func nullable(data []byte) interface{} {
+	if len(data) == 0 {
+		return nil
+	}
+	return string(data)
+}
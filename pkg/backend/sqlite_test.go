@@ -0,0 +1,126 @@
+package backend
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestSQLiteStoreAndQuery(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "changes.db")
+	b, err := NewSQLiteBackend(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer b.Close()
+
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	stored := []Change{
+		{GVK: "apps-v1.Deployment", Namespace: "default", Name: "app", User: "alice", FieldManager: "kubectl", Operation: "CREATE",
+			Timestamp: now, NewObject: json.RawMessage(`{"spec":{"replicas":1}}`), Diff: "+ replicas: 1", Groups: []string{"dev"}},
+		{GVK: "apps-v1.Deployment", Namespace: "default", Name: "app", User: "bob", FieldManager: "helm", Operation: "UPDATE",
+			Timestamp: now.Add(time.Minute), OldObject: json.RawMessage(`{"spec":{"replicas":1}}`), NewObject: json.RawMessage(`{"spec":{"replicas":2}}`), Diff: "- replicas: 1\n+ replicas: 2"},
+		{GVK: "v1.ConfigMap", Namespace: "default", Name: "cm", User: "alice", FieldManager: "kubectl", Operation: "DELETE",
+			Timestamp: now.Add(2 * time.Minute), OldObject: json.RawMessage(`{"data":{"a":"b"}}`)},
+	}
+	for _, c := range stored {
+		if err := b.Store(ctx, c); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	rows, err := b.db.QueryContext(ctx, `SELECT actor, operation, old_object, new_object, actor_groups FROM changes
+		WHERE gvk = ? AND namespace = ? AND name = ? ORDER BY timestamp`, "apps-v1.Deployment", "default", "app")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+
+	var got []Change
+	for rows.Next() {
+		var c Change
+		var oldObject, newObject, groups sql.NullString
+		if err := rows.Scan(&c.User, &c.Operation, &oldObject, &newObject, &groups); err != nil {
+			t.Fatal(err)
+		}
+		c.OldObject, c.NewObject = json.RawMessage(oldObject.String), json.RawMessage(newObject.String)
+		if groups.Valid {
+			if err := json.Unmarshal([]byte(groups.String), &c.Groups); err != nil {
+				t.Fatal(err)
+			}
+		}
+		got = append(got, c)
+	}
+	if err := rows.Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(got) != 2 {
+		t.Fatalf("expected the 2 changes of the deployment, got %d", len(got))
+	}
+	for i, c := range got {
+		want := stored[i]
+		if c.User != want.User || c.Operation != want.Operation || string(c.OldObject) != string(want.OldObject) ||
+			string(c.NewObject) != string(want.NewObject) || len(c.Groups) != len(want.Groups) {
+			t.Errorf("expected change %d to be %+v, got %+v", i, want, c)
+		}
+	}
+}
+
+func TestSQLiteMigratesExistingDatabase(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "changes.db")
+
+	// a database created before the actor columns were added
+	db, err := sql.Open("sqlite3", "file:"+path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Exec(`CREATE TABLE schema_version (version INTEGER NOT NULL)`); err != nil {
+		t.Fatal(err)
+	}
+	for i, m := range migrations[:2] {
+		if _, err := db.Exec(m); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := db.Exec(`INSERT INTO schema_version (version) VALUES (?)`, i+1); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := db.Exec(`INSERT INTO changes (gvk, namespace, name, actor, field_manager, operation, timestamp, diff)
+		VALUES ('v1.ConfigMap', 'default', 'cm', 'alice', 'kubectl', 'CREATE', ?, '')`, time.Now().UTC()); err != nil {
+		t.Fatal(err)
+	}
+	db.Close()
+
+	// opening twice must not apply a migration again
+	for i := 0; i < 2; i++ {
+		b, err := NewSQLiteBackend(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := b.Store(ctx, Change{GVK: "v1.ConfigMap", Namespace: "default", Name: "cm", User: "bob", Operation: "UPDATE", Groups: []string{"ops"}}); err != nil {
+			t.Fatal(err)
+		}
+
+		var version, count int
+		if err := b.db.QueryRow(`SELECT MAX(version) FROM schema_version`).Scan(&version); err != nil {
+			t.Fatal(err)
+		}
+		if err := b.db.QueryRow(`SELECT COUNT(*) FROM changes`).Scan(&count); err != nil {
+			t.Fatal(err)
+		}
+		b.Close()
+
+		if version != len(migrations) {
+			t.Errorf("expected schema version %d, got %d", len(migrations), version)
+		}
+		if count != i+2 {
+			t.Errorf("expected the existing rows to be kept, got %d rows", count)
+		}
+	}
+}
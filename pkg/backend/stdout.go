@@ -0,0 +1,33 @@
+package backend
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+)
+
+// StdoutBackend writes every change as a line of json, e.g. to stdout for log collectors
+type StdoutBackend struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func NewStdoutBackend(w io.Writer) *StdoutBackend {
+	return &StdoutBackend{w: w}
+}
+
+func (b *StdoutBackend) Store(ctx context.Context, c Change) error {
+	data, err := json.Marshal(c)
+	if err != nil {
+		return fmt.Errorf("failed to marshal change, err: %s", err)
+	}
+
+	// lines of concurrent changes must not interleave
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	_, err = b.w.Write(append(data, '\n'))
+
+	return err
+}
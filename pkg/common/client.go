@@ -2,23 +2,28 @@ package common
 
 import (
 	"context"
+	"encoding/base64"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"reflect"
+	"strings"
 	"time"
 
-	"gopkg.in/yaml.v2"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/equality"
 	utilerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/tools/clientcmd"
 	"k8s.io/client-go/util/retry"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/yaml"
 )
 
 //go:generate mockgen --build_flags=--mod=mod -package mocks -destination mocks/client_mock.go -source=client.go WrappedClient
@@ -29,15 +34,26 @@ type WrappedClient interface {
 	GetAndPatch(ctx context.Context, obj client.Object, f func() error) (controllerutil.OperationResult, error)
 	CreateOrPatchWithJsonMerge(ctx context.Context, obj client.Object, f func() error) (controllerutil.OperationResult, error)
 	CreateIfNotExist(ctx context.Context, obj client.Object) error
+	DeleteIfExists(ctx context.Context, obj client.Object) error
+	EnsureFinalizer(ctx context.Context, obj client.Object, finalizer string) (bool, error)
+	RemoveFinalizer(ctx context.Context, obj client.Object, finalizer string) (bool, error)
+	ListAllPages(ctx context.Context, list client.ObjectList, opts ...client.ListOption) error
 	UpdateStatus(ctx context.Context, obj client.Object) error
+	PatchStatus(ctx context.Context, obj client.Object, patch client.Patch) error
+	ServerSideApply(ctx context.Context, obj client.Object, fieldManager string, force bool) error
 	// TODO we might need to pass the structure SecretRef as the parameter instead of name, namespace and field
 	GetNonEmptySecretField(ctx context.Context, namespace, name, field string) ([]byte, error)
+	GetDecodedSecretField(ctx context.Context, namespace, name, field string) ([]byte, error)
+	GetGardenerKubeconfig(ctx context.Context, namespace, secretName string) ([]byte, error)
 	GetNonEmptyConfigMapField(ctx context.Context, namespace, name, field string) (string, error)
 	GetConfigMapFieldYamlUnmarshal(ctx context.Context, namespace, name, field string, obj interface{}) error
+	GetConfigMapFieldJSONUnmarshal(ctx context.Context, namespace, name, field string, obj interface{}) error
 }
 
 type ClientOptions struct {
 	Backoff wait.Backoff
+	// PageSize is the limit of every list request of ListAllPages
+	PageSize int64
 }
 
 type ClientOption func(*ClientOptions)
@@ -55,10 +71,14 @@ type richClient struct {
 
 var (
 	gardenerSecretProjectField = "project"
+	kubeconfigSecretField      = "kubeconfig"
+	gardenerProjectPrefix      = "garden-"
 	richClientLog              = ctrl.Log.WithName("richClient")
 	ErrEmptyKubeconfig         = errors.New("empty kubeconfig field data")
 	errEmptyGardenerProject    = errors.New("empty project field data")
 
+	defaultPageSize int64 = 500
+
 	defaultBackoff = wait.Backoff{
 		Steps:    5,
 		Duration: 1 * time.Second,
@@ -71,7 +91,8 @@ func NewClient(c client.Client, opts ...ClientOption) Client {
 	client := &richClient{
 		Client: c,
 		ClientOptions: ClientOptions{
-			Backoff: defaultBackoff,
+			Backoff:  defaultBackoff,
+			PageSize: defaultPageSize,
 		}}
 	client.ApplyOptions(opts...)
 	return client
@@ -83,6 +104,25 @@ func (c *richClient) ApplyOptions(opts ...ClientOption) {
 	}
 }
 
+// WithBackoff sets the backoff of the retries of the client
+func WithBackoff(backoff wait.Backoff) ClientOption {
+	return func(o *ClientOptions) {
+		o.Backoff = backoff
+	}
+}
+
+// WithRetrySteps sets the number of attempts of every retried request, at least one attempt is always made
+func WithRetrySteps(steps int) ClientOption {
+	return func(o *ClientOptions) {
+		o.Backoff.Steps = max(steps, 1)
+	}
+}
+
+// WithNoRetry makes every request once, failures are returned right away
+func WithNoRetry() ClientOption {
+	return WithRetrySteps(1)
+}
+
 // copy from sigs.k8s.io/controller-runtime/pkg/controller/controllerutil/controllerutil.go
 // mutate wraps a func() error and applies validation to its result.
 func mutate(f func() error, key client.ObjectKey, obj client.Object) error {
@@ -252,6 +292,24 @@ func (c *richClient) GetAndUpdate(ctx context.Context, obj client.Object, f func
 	return result, err
 }
 
+// EnsureFinalizer adds finalizer to the current version of obj, it reports whether obj was updated
+func (c *richClient) EnsureFinalizer(ctx context.Context, obj client.Object, finalizer string) (bool, error) {
+	result, err := c.GetAndUpdate(ctx, obj, func() error {
+		controllerutil.AddFinalizer(obj, finalizer)
+		return nil
+	})
+	return result == controllerutil.OperationResultUpdated, err
+}
+
+// RemoveFinalizer removes finalizer from the current version of obj, it reports whether obj was updated
+func (c *richClient) RemoveFinalizer(ctx context.Context, obj client.Object, finalizer string) (bool, error) {
+	result, err := c.GetAndUpdate(ctx, obj, func() error {
+		controllerutil.RemoveFinalizer(obj, finalizer)
+		return nil
+	})
+	return result == controllerutil.OperationResultUpdated, err
+}
+
 func (c *richClient) CreateOrPatchWithJsonMerge(ctx context.Context, obj client.Object, f func() error) (result controllerutil.OperationResult, err error) {
 	err = retry.RetryOnConflict(c.Backoff, func() (err error) {
 		result, err = createOrPatchWithJsonMerge(ctx, c.Client, obj, f)
@@ -296,6 +354,63 @@ func (c *richClient) CreateIfNotExist(ctx context.Context, obj client.Object) er
 	})
 }
 
+// DeleteIfExists deletes obj unless it's gone already. The delete is conditioned on the version just read, so an
+// object replaced meanwhile under the same name is not deleted blindly but read and deleted again.
+func (c *richClient) DeleteIfExists(ctx context.Context, obj client.Object) error {
+	return retry.RetryOnConflict(c.Backoff, func() error {
+		if err := c.Get(ctx, client.ObjectKeyFromObject(obj), obj); err != nil {
+			return client.IgnoreNotFound(err)
+		}
+
+		uid, resourceVersion := obj.GetUID(), obj.GetResourceVersion()
+		err := c.Delete(ctx, obj, client.Preconditions{UID: &uid, ResourceVersion: &resourceVersion})
+		return client.IgnoreNotFound(err)
+	})
+}
+
+// ListAllPages lists PageSize items at a time and follows the continue token until all items are collected in list,
+// transient errors of a page are retried with the backoff
+func (c *richClient) ListAllPages(ctx context.Context, list client.ObjectList, opts ...client.ListOption) error {
+	var items []runtime.Object
+	var resourceVersion, token string
+	for {
+		page := list.DeepCopyObject().(client.ObjectList)
+		pageOpts := append(append([]client.ListOption{}, opts...), client.Limit(c.PageSize), client.Continue(token))
+		if err := retry.OnError(c.Backoff, isTransient, func() error {
+			return c.List(ctx, page, pageOpts...)
+		}); err != nil {
+			return err
+		}
+
+		pageItems, err := meta.ExtractList(page)
+		if err != nil {
+			return err
+		}
+		items = append(items, pageItems...)
+
+		if resourceVersion == "" {
+			resourceVersion = page.GetResourceVersion()
+		}
+		if token = page.GetContinue(); token == "" {
+			break
+		}
+	}
+
+	if err := meta.SetList(list, items); err != nil {
+		return err
+	}
+	list.SetResourceVersion(resourceVersion)
+	list.SetContinue("")
+
+	return nil
+}
+
+// isTransient reports whether a request failed for a reason which may go away when it is retried
+func isTransient(err error) bool {
+	return utilerrors.IsServerTimeout(err) || utilerrors.IsTimeout(err) || utilerrors.IsTooManyRequests(err) ||
+		utilerrors.IsServiceUnavailable(err) || utilerrors.IsInternalError(err)
+}
+
 func (c *richClient) UpdateStatus(ctx context.Context, obj client.Object) error {
 	return retry.RetryOnConflict(c.Backoff, func() error {
 		err := c.Status().Update(ctx, obj)
@@ -303,6 +418,31 @@ func (c *richClient) UpdateStatus(ctx context.Context, obj client.Object) error
 	})
 }
 
+// PatchStatus patches the status of obj, retrying transient failures only. Patches made with
+// client.MergeFromWithOptimisticLock fail with a conflict when obj changed in the meantime, the conflict is
+// returned as it is so callers can tell it with IsConflict, re-read obj and patch again.
+func (c *richClient) PatchStatus(ctx context.Context, obj client.Object, patch client.Patch) error {
+	return retry.OnError(c.Backoff, isTransient, func() error {
+		return c.Status().Patch(ctx, obj, patch)
+	})
+}
+
+// ServerSideApply applies obj as fieldManager, obj needs its apiVersion and kind and only the fields owned by
+// fieldManager. Without force, fields owned by other managers fail the apply with a conflict, which is returned as
+// it is, force takes them over. Transient failures are retried.
+func (c *richClient) ServerSideApply(ctx context.Context, obj client.Object, fieldManager string, force bool) error {
+	opts := []client.PatchOption{client.FieldOwner(fieldManager)}
+	if force {
+		opts = append(opts, client.ForceOwnership)
+	}
+	// the api server rejects applied objects carrying managedFields
+	obj.SetManagedFields(nil)
+
+	return retry.OnError(c.Backoff, isTransient, func() error {
+		return c.Patch(ctx, obj, client.Apply, opts...)
+	})
+}
+
 func (c *richClient) GetNonEmptySecretField(ctx context.Context, namespace, name, field string) ([]byte, error) {
 	secret := &corev1.Secret{}
 	if err := c.Get(ctx, types.NamespacedName{Namespace: namespace, Name: name}, secret); err != nil {
@@ -319,6 +459,49 @@ func (c *richClient) GetNonEmptySecretField(ctx context.Context, namespace, name
 	return secret.Data[field], nil
 }
 
+// GetDecodedSecretField returns the field base64 decoded once more, for data stored base64 encoded in the secret,
+// e.g. nested kubeconfigs
+func (c *richClient) GetDecodedSecretField(ctx context.Context, namespace, name, field string) ([]byte, error) {
+	data, err := c.GetNonEmptySecretField(ctx, namespace, name, field)
+	if err != nil {
+		return nil, err
+	}
+
+	decoded, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(data)))
+	if err != nil {
+		return nil, fmt.Errorf("field %s in secret %s is not valid base64: %s", field, name, err)
+	}
+	return decoded, nil
+}
+
+// GetGardenerKubeconfig returns the kubeconfig of a gardener service account secret, with the namespace of the
+// contexts set to the namespace of the project of the secret, garden-<project>
+func (c *richClient) GetGardenerKubeconfig(ctx context.Context, namespace, secretName string) ([]byte, error) {
+	secret := &corev1.Secret{}
+	if err := c.Get(ctx, types.NamespacedName{Namespace: namespace, Name: secretName}, secret); err != nil {
+		return nil, err
+	}
+
+	kubeconfig := secret.Data[kubeconfigSecretField]
+	if len(kubeconfig) == 0 {
+		return nil, fmt.Errorf("%w, secret: %s/%s", ErrEmptyKubeconfig, namespace, secretName)
+	}
+	project := strings.TrimSpace(string(secret.Data[gardenerSecretProjectField]))
+	if project == "" {
+		return nil, fmt.Errorf("%w, secret: %s/%s", errEmptyGardenerProject, namespace, secretName)
+	}
+
+	config, err := clientcmd.Load(kubeconfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse kubeconfig, secret: %s/%s, err: %s", namespace, secretName, err)
+	}
+	for _, kubeContext := range config.Contexts {
+		kubeContext.Namespace = gardenerProjectPrefix + project
+	}
+
+	return clientcmd.Write(*config)
+}
+
 func (c *richClient) GetNonEmptyConfigMapField(ctx context.Context, namespace, name, field string) (string, error) {
 	cm := &corev1.ConfigMap{}
 	if err := c.Get(ctx, types.NamespacedName{Namespace: namespace, Name: name}, cm); err != nil {
@@ -331,6 +514,8 @@ func (c *richClient) GetNonEmptyConfigMapField(ctx context.Context, namespace, n
 	return cm.Data[field], nil
 }
 
+// GetConfigMapFieldYamlUnmarshal unmarshals the yaml field into obj. The yaml is converted to json first, so obj is
+// decoded by its json tags and untyped maps come out as map[string]interface{}.
 func (c *richClient) GetConfigMapFieldYamlUnmarshal(ctx context.Context, namespace, name, field string, obj interface{}) error {
 	s, err := c.GetNonEmptyConfigMapField(ctx, namespace, name, field)
 	if err != nil {
@@ -339,3 +524,16 @@ func (c *richClient) GetConfigMapFieldYamlUnmarshal(ctx context.Context, namespa
 
 	return yaml.Unmarshal([]byte(s), obj)
 }
+
+// GetConfigMapFieldJSONUnmarshal unmarshals the json field into obj
+func (c *richClient) GetConfigMapFieldJSONUnmarshal(ctx context.Context, namespace, name, field string, obj interface{}) error {
+	s, err := c.GetNonEmptyConfigMapField(ctx, namespace, name, field)
+	if err != nil {
+		return err
+	}
+
+	if err := json.Unmarshal([]byte(s), obj); err != nil {
+		return fmt.Errorf("field %s in configmap %s is not valid json: %s", field, name, err)
+	}
+	return nil
+}
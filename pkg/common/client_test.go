@@ -0,0 +1,404 @@
+package common
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	utilerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/tools/clientcmd"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+var testBackoff = wait.Backoff{Steps: 3, Duration: time.Millisecond}
+
+// newTestClient creates a client on a fake api server holding objs, funcs intercept its requests
+func newTestClient(funcs interceptor.Funcs, objs ...client.Object) Client {
+	c := fake.NewClientBuilder().
+		WithObjects(objs...).
+		WithStatusSubresource(&corev1.Pod{}).
+		WithInterceptorFuncs(funcs).
+		Build()
+	return NewClient(c, WithBackoff(testBackoff))
+}
+
+func configMap() *corev1.ConfigMap {
+	return &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "cm", Namespace: "default"}}
+}
+
+var configMapResource = schema.GroupResource{Resource: "configmaps"}
+
+func TestDeleteIfExists(t *testing.T) {
+	ctx := context.Background()
+	forbidden := utilerrors.NewForbidden(configMapResource, "cm", nil)
+
+	cases := []struct {
+		name      string
+		objs      []client.Object
+		deleteErr []error
+		wantErr   error
+		wantCalls int
+	}{
+		{name: "existing object", objs: []client.Object{configMap()}, wantCalls: 1},
+		{name: "missing object"},
+		{name: "conflict is retried", objs: []client.Object{configMap()}, deleteErr: []error{utilerrors.NewConflict(configMapResource, "cm", nil)}, wantCalls: 2},
+		{name: "deleted meanwhile", objs: []client.Object{configMap()}, deleteErr: []error{utilerrors.NewNotFound(configMapResource, "cm")}, wantCalls: 1},
+		{name: "other errors are returned", objs: []client.Object{configMap()}, deleteErr: []error{forbidden}, wantErr: forbidden, wantCalls: 1},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			calls := 0
+			c := newTestClient(interceptor.Funcs{
+				Delete: func(ctx context.Context, c client.WithWatch, obj client.Object, opts ...client.DeleteOption) error {
+					calls++
+					if calls <= len(tc.deleteErr) {
+						if utilerrors.IsNotFound(tc.deleteErr[calls-1]) {
+							// someone else deleted the object after it was read
+							_ = c.Delete(ctx, obj)
+						}
+						return tc.deleteErr[calls-1]
+					}
+					return c.Delete(ctx, obj, opts...)
+				},
+			}, tc.objs...)
+
+			err := c.DeleteIfExists(ctx, configMap())
+			if err != tc.wantErr {
+				t.Fatalf("expected error %v, got %v", tc.wantErr, err)
+			}
+			if calls != tc.wantCalls {
+				t.Errorf("expected %d deletes, got %d", tc.wantCalls, calls)
+			}
+			if tc.wantErr != nil {
+				return
+			}
+			if err := c.Get(ctx, client.ObjectKeyFromObject(configMap()), &corev1.ConfigMap{}); !utilerrors.IsNotFound(err) {
+				t.Errorf("expected the object to be gone, got %v", err)
+			}
+		})
+	}
+}
+
+func TestDeleteIfExistsKeepsReplacedObject(t *testing.T) {
+	ctx := context.Background()
+	c := newTestClient(interceptor.Funcs{
+		Delete: func(ctx context.Context, c client.WithWatch, obj client.Object, opts ...client.DeleteOption) error {
+			// the object is replaced between the read and the delete, the precondition must reject the stale delete
+			cm := configMap()
+			if err := c.Get(ctx, client.ObjectKeyFromObject(cm), cm); err != nil {
+				return err
+			}
+			cm.Data = map[string]string{"a": "b"}
+			if err := c.Update(ctx, cm); err != nil {
+				return err
+			}
+			return c.Delete(ctx, obj, opts...)
+		},
+	}, configMap())
+
+	err := c.DeleteIfExists(ctx, configMap())
+	if !utilerrors.IsConflict(err) {
+		t.Fatalf("expected a conflict after the retries, got %v", err)
+	}
+	if err := c.Get(ctx, client.ObjectKeyFromObject(configMap()), &corev1.ConfigMap{}); err != nil {
+		t.Errorf("expected the replaced object to be kept, got %v", err)
+	}
+}
+
+func pod() *corev1.Pod {
+	return &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "pod", Namespace: "default"}}
+}
+
+func TestPatchStatus(t *testing.T) {
+	ctx := context.Background()
+	podResource := schema.GroupResource{Resource: "pods"}
+
+	cases := []struct {
+		name      string
+		patchErr  []error
+		wantErr   bool
+		wantCalls int
+	}{
+		{name: "patched", wantCalls: 1},
+		{name: "transient errors are retried", patchErr: []error{utilerrors.NewServiceUnavailable("busy")}, wantCalls: 2},
+		{name: "conflicts are not retried", patchErr: []error{utilerrors.NewConflict(podResource, "pod", nil)}, wantErr: true, wantCalls: 1},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			calls := 0
+			c := newTestClient(interceptor.Funcs{
+				SubResourcePatch: func(ctx context.Context, c client.Client, subResourceName string, obj client.Object, patch client.Patch, opts ...client.SubResourcePatchOption) error {
+					calls++
+					if calls <= len(tc.patchErr) {
+						return tc.patchErr[calls-1]
+					}
+					return c.SubResource(subResourceName).Patch(ctx, obj, patch, opts...)
+				},
+			}, pod())
+
+			p := pod()
+			if err := c.Get(ctx, client.ObjectKeyFromObject(p), p); err != nil {
+				t.Fatal(err)
+			}
+			patch := client.MergeFromWithOptions(p.DeepCopy(), client.MergeFromWithOptimisticLock{})
+			p.Status.Phase = corev1.PodRunning
+
+			err := c.PatchStatus(ctx, p, patch)
+			if tc.wantErr != (err != nil) {
+				t.Fatalf("expected error %v, got %v", tc.wantErr, err)
+			}
+			if tc.wantErr && !utilerrors.IsConflict(err) {
+				t.Errorf("expected the conflict unwrapped, got %v", err)
+			}
+			if calls != tc.wantCalls {
+				t.Errorf("expected %d patches, got %d", tc.wantCalls, calls)
+			}
+			if tc.wantErr {
+				return
+			}
+			got := pod()
+			if err := c.Get(ctx, client.ObjectKeyFromObject(got), got); err != nil {
+				t.Fatal(err)
+			}
+			if got.Status.Phase != corev1.PodRunning {
+				t.Errorf("expected the status to be patched, got %s", got.Status.Phase)
+			}
+		})
+	}
+}
+
+func TestPatchStatusOptimisticLock(t *testing.T) {
+	ctx := context.Background()
+	c := newTestClient(interceptor.Funcs{}, pod())
+
+	stale := pod()
+	if err := c.Get(ctx, client.ObjectKeyFromObject(stale), stale); err != nil {
+		t.Fatal(err)
+	}
+
+	// a concurrent writer changes the status after stale was read
+	current := stale.DeepCopy()
+	current.Status.Phase = corev1.PodFailed
+	if err := c.Status().Update(ctx, current); err != nil {
+		t.Fatal(err)
+	}
+
+	patch := client.MergeFromWithOptions(stale.DeepCopy(), client.MergeFromWithOptimisticLock{})
+	stale.Status.Phase = corev1.PodRunning
+	if err := c.PatchStatus(ctx, stale, patch); !utilerrors.IsConflict(err) {
+		t.Fatalf("expected a conflict, got %v", err)
+	}
+
+	got := pod()
+	if err := c.Get(ctx, client.ObjectKeyFromObject(got), got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Status.Phase != corev1.PodFailed {
+		t.Errorf("expected the concurrent status to be kept, got %s", got.Status.Phase)
+	}
+}
+
+const testKubeconfig = `apiVersion: v1
+kind: Config
+clusters:
+- name: garden
+  cluster:
+    server: https://garden.example.com
+contexts:
+- name: garden
+  context:
+    cluster: garden
+    user: robot
+current-context: garden
+users:
+- name: robot
+  user:
+    token: secret
+`
+
+func TestGetGardenerKubeconfig(t *testing.T) {
+	ctx := context.Background()
+
+	cases := []struct {
+		name    string
+		data    map[string][]byte
+		wantErr error
+	}{
+		{name: "kubeconfig and project", data: map[string][]byte{"kubeconfig": []byte(testKubeconfig), "project": []byte("dev\n")}},
+		{name: "empty kubeconfig", data: map[string][]byte{"kubeconfig": {}, "project": []byte("dev")}, wantErr: ErrEmptyKubeconfig},
+		{name: "missing kubeconfig", data: map[string][]byte{"project": []byte("dev")}, wantErr: ErrEmptyKubeconfig},
+		{name: "empty project", data: map[string][]byte{"kubeconfig": []byte(testKubeconfig), "project": []byte(" ")}, wantErr: errEmptyGardenerProject},
+		{name: "missing project", data: map[string][]byte{"kubeconfig": []byte(testKubeconfig)}, wantErr: errEmptyGardenerProject},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			secret := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "robot", Namespace: "garden-dev"}, Data: tc.data}
+			c := newTestClient(interceptor.Funcs{}, secret)
+
+			kubeconfig, err := c.GetGardenerKubeconfig(ctx, "garden-dev", "robot")
+			if tc.wantErr != nil {
+				if !errors.Is(err, tc.wantErr) {
+					t.Fatalf("expected %v, got %v", tc.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			config, err := clientcmd.Load(kubeconfig)
+			if err != nil {
+				t.Fatalf("expected a usable kubeconfig: %s", err)
+			}
+			if ns := config.Contexts["garden"].Namespace; ns != "garden-dev" {
+				t.Errorf("expected the context namespace garden-dev, got %q", ns)
+			}
+			if token := config.AuthInfos["robot"].Token; token != "secret" {
+				t.Errorf("expected the credentials to be kept, got %q", token)
+			}
+		})
+	}
+}
+
+func TestFinalizers(t *testing.T) {
+	ctx := context.Background()
+	const finalizer = "example.com/cleanup"
+
+	conflicts := 1
+	c := newTestClient(interceptor.Funcs{
+		Update: func(ctx context.Context, c client.WithWatch, obj client.Object, opts ...client.UpdateOption) error {
+			if conflicts > 0 {
+				conflicts--
+				return utilerrors.NewConflict(configMapResource, obj.GetName(), nil)
+			}
+			return c.Update(ctx, obj, opts...)
+		},
+	}, configMap())
+
+	steps := []struct {
+		name   string
+		do     func(ctx context.Context, obj client.Object, finalizer string) (bool, error)
+		change bool
+		held   bool
+	}{
+		{name: "ensure after a conflict", do: c.EnsureFinalizer, change: true, held: true},
+		{name: "ensure again", do: c.EnsureFinalizer, held: true},
+		{name: "remove", do: c.RemoveFinalizer, change: true},
+		{name: "remove again", do: c.RemoveFinalizer},
+	}
+
+	for _, s := range steps {
+		changed, err := s.do(ctx, configMap(), finalizer)
+		if err != nil {
+			t.Fatalf("%s: %s", s.name, err)
+		}
+		if changed != s.change {
+			t.Errorf("%s: expected changed %v, got %v", s.name, s.change, changed)
+		}
+
+		got := configMap()
+		if err := c.Get(ctx, client.ObjectKeyFromObject(got), got); err != nil {
+			t.Fatal(err)
+		}
+		if held := controllerutil.ContainsFinalizer(got, finalizer); held != s.held {
+			t.Errorf("%s: expected the finalizer held %v, got %v", s.name, s.held, held)
+		}
+	}
+	if conflicts != 0 {
+		t.Error("expected the conflict to be retried")
+	}
+}
+
+func TestFinalizersMissingObject(t *testing.T) {
+	c := newTestClient(interceptor.Funcs{})
+	if _, err := c.EnsureFinalizer(context.Background(), configMap(), "example.com/cleanup"); !utilerrors.IsNotFound(err) {
+		t.Errorf("expected not found, got %v", err)
+	}
+}
+
+func TestServerSideApply(t *testing.T) {
+	ctx := context.Background()
+
+	cases := []struct {
+		name    string
+		force   bool
+		wantErr bool
+	}{
+		{name: "conflict without force", wantErr: true},
+		{name: "force takes over the fields", force: true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			calls := 0
+			var applied *client.PatchOptions
+			// the fake client can't apply, the api server is emulated with the fields of cm owned by another manager
+			c := newTestClient(interceptor.Funcs{
+				Patch: func(ctx context.Context, c client.WithWatch, obj client.Object, patch client.Patch, opts ...client.PatchOption) error {
+					calls++
+					if patch.Type() != types.ApplyPatchType {
+						t.Fatalf("expected an apply patch, got %s", patch.Type())
+					}
+					if len(obj.GetManagedFields()) > 0 {
+						t.Fatal("expected the managed fields to be dropped")
+					}
+					applied = (&client.PatchOptions{}).ApplyOptions(opts)
+					if applied.Force == nil || !*applied.Force {
+						return utilerrors.NewApplyConflict(nil, "conflict with \"kubectl\": .data.a")
+					}
+					return nil
+				},
+			})
+
+			obj := configMap()
+			obj.Data = map[string]string{"a": "b"}
+			obj.SetManagedFields([]metav1.ManagedFieldsEntry{{Manager: "kubectl"}})
+
+			err := c.ServerSideApply(ctx, obj, "tracer", tc.force)
+			if tc.wantErr != (err != nil) {
+				t.Fatalf("expected error %v, got %v", tc.wantErr, err)
+			}
+			if tc.wantErr && !utilerrors.IsConflict(err) {
+				t.Errorf("expected the conflict unwrapped, got %v", err)
+			}
+			if calls != 1 {
+				t.Errorf("expected a single apply, got %d", calls)
+			}
+			if applied.FieldManager != "tracer" {
+				t.Errorf("expected field manager tracer, got %q", applied.FieldManager)
+			}
+		})
+	}
+}
+
+func TestServerSideApplyRetriesTransientErrors(t *testing.T) {
+	calls := 0
+	c := newTestClient(interceptor.Funcs{
+		Patch: func(ctx context.Context, c client.WithWatch, obj client.Object, patch client.Patch, opts ...client.PatchOption) error {
+			calls++
+			if calls == 1 {
+				return utilerrors.NewTooManyRequests("slow down", 0)
+			}
+			return nil
+		},
+	})
+
+	if err := c.ServerSideApply(context.Background(), configMap(), "tracer", false); err != nil {
+		t.Fatal(err)
+	}
+	if calls != 2 {
+		t.Errorf("expected the apply to be retried once, got %d calls", calls)
+	}
+}
@@ -0,0 +1,868 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: client.go
+//
+// Generated by this command:
+//
+//	mockgen --build_flags=--mod=mod -package mocks -destination mocks/client_mock.go -source=client.go WrappedClient
+//
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+
+	common "github.com/reborn1867/k8s-resource-tracer/pkg/common"
+	gomock "go.uber.org/mock/gomock"
+	meta "k8s.io/apimachinery/pkg/api/meta"
+	runtime "k8s.io/apimachinery/pkg/runtime"
+	schema "k8s.io/apimachinery/pkg/runtime/schema"
+	client "sigs.k8s.io/controller-runtime/pkg/client"
+	controllerutil "sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+// MockWrappedClient is a mock of WrappedClient interface.
+type MockWrappedClient struct {
+	ctrl     *gomock.Controller
+	recorder *MockWrappedClientMockRecorder
+}
+
+// MockWrappedClientMockRecorder is the mock recorder for MockWrappedClient.
+type MockWrappedClientMockRecorder struct {
+	mock *MockWrappedClient
+}
+
+// NewMockWrappedClient creates a new mock instance.
+func NewMockWrappedClient(ctrl *gomock.Controller) *MockWrappedClient {
+	mock := &MockWrappedClient{ctrl: ctrl}
+	mock.recorder = &MockWrappedClientMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockWrappedClient) EXPECT() *MockWrappedClientMockRecorder {
+	return m.recorder
+}
+
+// CreateIfNotExist mocks base method.
+func (m *MockWrappedClient) CreateIfNotExist(ctx context.Context, obj client.Object) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateIfNotExist", ctx, obj)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateIfNotExist indicates an expected call of CreateIfNotExist.
+func (mr *MockWrappedClientMockRecorder) CreateIfNotExist(ctx, obj any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateIfNotExist", reflect.TypeOf((*MockWrappedClient)(nil).CreateIfNotExist), ctx, obj)
+}
+
+// CreateOrPatch mocks base method.
+func (m *MockWrappedClient) CreateOrPatch(ctx context.Context, obj client.Object, f func() error) (controllerutil.OperationResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateOrPatch", ctx, obj, f)
+	ret0, _ := ret[0].(controllerutil.OperationResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateOrPatch indicates an expected call of CreateOrPatch.
+func (mr *MockWrappedClientMockRecorder) CreateOrPatch(ctx, obj, f any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateOrPatch", reflect.TypeOf((*MockWrappedClient)(nil).CreateOrPatch), ctx, obj, f)
+}
+
+// CreateOrPatchWithJsonMerge mocks base method.
+func (m *MockWrappedClient) CreateOrPatchWithJsonMerge(ctx context.Context, obj client.Object, f func() error) (controllerutil.OperationResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateOrPatchWithJsonMerge", ctx, obj, f)
+	ret0, _ := ret[0].(controllerutil.OperationResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateOrPatchWithJsonMerge indicates an expected call of CreateOrPatchWithJsonMerge.
+func (mr *MockWrappedClientMockRecorder) CreateOrPatchWithJsonMerge(ctx, obj, f any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateOrPatchWithJsonMerge", reflect.TypeOf((*MockWrappedClient)(nil).CreateOrPatchWithJsonMerge), ctx, obj, f)
+}
+
+// CreateOrUpdate mocks base method.
+func (m *MockWrappedClient) CreateOrUpdate(ctx context.Context, obj client.Object, f func() error) (controllerutil.OperationResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateOrUpdate", ctx, obj, f)
+	ret0, _ := ret[0].(controllerutil.OperationResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateOrUpdate indicates an expected call of CreateOrUpdate.
+func (mr *MockWrappedClientMockRecorder) CreateOrUpdate(ctx, obj, f any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateOrUpdate", reflect.TypeOf((*MockWrappedClient)(nil).CreateOrUpdate), ctx, obj, f)
+}
+
+// DeleteIfExists mocks base method.
+func (m *MockWrappedClient) DeleteIfExists(ctx context.Context, obj client.Object) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteIfExists", ctx, obj)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteIfExists indicates an expected call of DeleteIfExists.
+func (mr *MockWrappedClientMockRecorder) DeleteIfExists(ctx, obj any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteIfExists", reflect.TypeOf((*MockWrappedClient)(nil).DeleteIfExists), ctx, obj)
+}
+
+// EnsureFinalizer mocks base method.
+func (m *MockWrappedClient) EnsureFinalizer(ctx context.Context, obj client.Object, finalizer string) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "EnsureFinalizer", ctx, obj, finalizer)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// EnsureFinalizer indicates an expected call of EnsureFinalizer.
+func (mr *MockWrappedClientMockRecorder) EnsureFinalizer(ctx, obj, finalizer any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EnsureFinalizer", reflect.TypeOf((*MockWrappedClient)(nil).EnsureFinalizer), ctx, obj, finalizer)
+}
+
+// GetAndPatch mocks base method.
+func (m *MockWrappedClient) GetAndPatch(ctx context.Context, obj client.Object, f func() error) (controllerutil.OperationResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAndPatch", ctx, obj, f)
+	ret0, _ := ret[0].(controllerutil.OperationResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAndPatch indicates an expected call of GetAndPatch.
+func (mr *MockWrappedClientMockRecorder) GetAndPatch(ctx, obj, f any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAndPatch", reflect.TypeOf((*MockWrappedClient)(nil).GetAndPatch), ctx, obj, f)
+}
+
+// GetAndUpdate mocks base method.
+func (m *MockWrappedClient) GetAndUpdate(ctx context.Context, obj client.Object, f func() error) (controllerutil.OperationResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAndUpdate", ctx, obj, f)
+	ret0, _ := ret[0].(controllerutil.OperationResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAndUpdate indicates an expected call of GetAndUpdate.
+func (mr *MockWrappedClientMockRecorder) GetAndUpdate(ctx, obj, f any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAndUpdate", reflect.TypeOf((*MockWrappedClient)(nil).GetAndUpdate), ctx, obj, f)
+}
+
+// GetConfigMapFieldJSONUnmarshal mocks base method.
+func (m *MockWrappedClient) GetConfigMapFieldJSONUnmarshal(ctx context.Context, namespace, name, field string, obj any) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetConfigMapFieldJSONUnmarshal", ctx, namespace, name, field, obj)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// GetConfigMapFieldJSONUnmarshal indicates an expected call of GetConfigMapFieldJSONUnmarshal.
+func (mr *MockWrappedClientMockRecorder) GetConfigMapFieldJSONUnmarshal(ctx, namespace, name, field, obj any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetConfigMapFieldJSONUnmarshal", reflect.TypeOf((*MockWrappedClient)(nil).GetConfigMapFieldJSONUnmarshal), ctx, namespace, name, field, obj)
+}
+
+// GetConfigMapFieldYamlUnmarshal mocks base method.
+func (m *MockWrappedClient) GetConfigMapFieldYamlUnmarshal(ctx context.Context, namespace, name, field string, obj any) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetConfigMapFieldYamlUnmarshal", ctx, namespace, name, field, obj)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// GetConfigMapFieldYamlUnmarshal indicates an expected call of GetConfigMapFieldYamlUnmarshal.
+func (mr *MockWrappedClientMockRecorder) GetConfigMapFieldYamlUnmarshal(ctx, namespace, name, field, obj any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetConfigMapFieldYamlUnmarshal", reflect.TypeOf((*MockWrappedClient)(nil).GetConfigMapFieldYamlUnmarshal), ctx, namespace, name, field, obj)
+}
+
+// GetDecodedSecretField mocks base method.
+func (m *MockWrappedClient) GetDecodedSecretField(ctx context.Context, namespace, name, field string) ([]byte, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetDecodedSecretField", ctx, namespace, name, field)
+	ret0, _ := ret[0].([]byte)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetDecodedSecretField indicates an expected call of GetDecodedSecretField.
+func (mr *MockWrappedClientMockRecorder) GetDecodedSecretField(ctx, namespace, name, field any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDecodedSecretField", reflect.TypeOf((*MockWrappedClient)(nil).GetDecodedSecretField), ctx, namespace, name, field)
+}
+
+// GetGardenerKubeconfig mocks base method.
+func (m *MockWrappedClient) GetGardenerKubeconfig(ctx context.Context, namespace, secretName string) ([]byte, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetGardenerKubeconfig", ctx, namespace, secretName)
+	ret0, _ := ret[0].([]byte)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetGardenerKubeconfig indicates an expected call of GetGardenerKubeconfig.
+func (mr *MockWrappedClientMockRecorder) GetGardenerKubeconfig(ctx, namespace, secretName any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetGardenerKubeconfig", reflect.TypeOf((*MockWrappedClient)(nil).GetGardenerKubeconfig), ctx, namespace, secretName)
+}
+
+// GetNonEmptyConfigMapField mocks base method.
+func (m *MockWrappedClient) GetNonEmptyConfigMapField(ctx context.Context, namespace, name, field string) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetNonEmptyConfigMapField", ctx, namespace, name, field)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetNonEmptyConfigMapField indicates an expected call of GetNonEmptyConfigMapField.
+func (mr *MockWrappedClientMockRecorder) GetNonEmptyConfigMapField(ctx, namespace, name, field any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetNonEmptyConfigMapField", reflect.TypeOf((*MockWrappedClient)(nil).GetNonEmptyConfigMapField), ctx, namespace, name, field)
+}
+
+// GetNonEmptySecretField mocks base method.
+func (m *MockWrappedClient) GetNonEmptySecretField(ctx context.Context, namespace, name, field string) ([]byte, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetNonEmptySecretField", ctx, namespace, name, field)
+	ret0, _ := ret[0].([]byte)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetNonEmptySecretField indicates an expected call of GetNonEmptySecretField.
+func (mr *MockWrappedClientMockRecorder) GetNonEmptySecretField(ctx, namespace, name, field any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetNonEmptySecretField", reflect.TypeOf((*MockWrappedClient)(nil).GetNonEmptySecretField), ctx, namespace, name, field)
+}
+
+// ListAllPages mocks base method.
+func (m *MockWrappedClient) ListAllPages(ctx context.Context, list client.ObjectList, opts ...client.ListOption) error {
+	m.ctrl.T.Helper()
+	varargs := []any{ctx, list}
+	for _, a := range opts {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "ListAllPages", varargs...)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ListAllPages indicates an expected call of ListAllPages.
+func (mr *MockWrappedClientMockRecorder) ListAllPages(ctx, list any, opts ...any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]any{ctx, list}, opts...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListAllPages", reflect.TypeOf((*MockWrappedClient)(nil).ListAllPages), varargs...)
+}
+
+// PatchStatus mocks base method.
+func (m *MockWrappedClient) PatchStatus(ctx context.Context, obj client.Object, patch client.Patch) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PatchStatus", ctx, obj, patch)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// PatchStatus indicates an expected call of PatchStatus.
+func (mr *MockWrappedClientMockRecorder) PatchStatus(ctx, obj, patch any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PatchStatus", reflect.TypeOf((*MockWrappedClient)(nil).PatchStatus), ctx, obj, patch)
+}
+
+// RemoveFinalizer mocks base method.
+func (m *MockWrappedClient) RemoveFinalizer(ctx context.Context, obj client.Object, finalizer string) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RemoveFinalizer", ctx, obj, finalizer)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RemoveFinalizer indicates an expected call of RemoveFinalizer.
+func (mr *MockWrappedClientMockRecorder) RemoveFinalizer(ctx, obj, finalizer any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoveFinalizer", reflect.TypeOf((*MockWrappedClient)(nil).RemoveFinalizer), ctx, obj, finalizer)
+}
+
+// ServerSideApply mocks base method.
+func (m *MockWrappedClient) ServerSideApply(ctx context.Context, obj client.Object, fieldManager string, force bool) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ServerSideApply", ctx, obj, fieldManager, force)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ServerSideApply indicates an expected call of ServerSideApply.
+func (mr *MockWrappedClientMockRecorder) ServerSideApply(ctx, obj, fieldManager, force any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ServerSideApply", reflect.TypeOf((*MockWrappedClient)(nil).ServerSideApply), ctx, obj, fieldManager, force)
+}
+
+// UpdateStatus mocks base method.
+func (m *MockWrappedClient) UpdateStatus(ctx context.Context, obj client.Object) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateStatus", ctx, obj)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateStatus indicates an expected call of UpdateStatus.
+func (mr *MockWrappedClientMockRecorder) UpdateStatus(ctx, obj any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateStatus", reflect.TypeOf((*MockWrappedClient)(nil).UpdateStatus), ctx, obj)
+}
+
+// MockClient is a mock of Client interface.
+type MockClient struct {
+	ctrl     *gomock.Controller
+	recorder *MockClientMockRecorder
+}
+
+// MockClientMockRecorder is the mock recorder for MockClient.
+type MockClientMockRecorder struct {
+	mock *MockClient
+}
+
+// NewMockClient creates a new mock instance.
+func NewMockClient(ctrl *gomock.Controller) *MockClient {
+	mock := &MockClient{ctrl: ctrl}
+	mock.recorder = &MockClientMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockClient) EXPECT() *MockClientMockRecorder {
+	return m.recorder
+}
+
+// ApplyOptions mocks base method.
+func (m *MockClient) ApplyOptions(arg0 ...common.ClientOption) {
+	m.ctrl.T.Helper()
+	varargs := []any{}
+	for _, a := range arg0 {
+		varargs = append(varargs, a)
+	}
+	m.ctrl.Call(m, "ApplyOptions", varargs...)
+}
+
+// ApplyOptions indicates an expected call of ApplyOptions.
+func (mr *MockClientMockRecorder) ApplyOptions(arg0 ...any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ApplyOptions", reflect.TypeOf((*MockClient)(nil).ApplyOptions), arg0...)
+}
+
+// Create mocks base method.
+func (m *MockClient) Create(ctx context.Context, obj client.Object, opts ...client.CreateOption) error {
+	m.ctrl.T.Helper()
+	varargs := []any{ctx, obj}
+	for _, a := range opts {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "Create", varargs...)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Create indicates an expected call of Create.
+func (mr *MockClientMockRecorder) Create(ctx, obj any, opts ...any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]any{ctx, obj}, opts...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockClient)(nil).Create), varargs...)
+}
+
+// CreateIfNotExist mocks base method.
+func (m *MockClient) CreateIfNotExist(ctx context.Context, obj client.Object) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateIfNotExist", ctx, obj)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateIfNotExist indicates an expected call of CreateIfNotExist.
+func (mr *MockClientMockRecorder) CreateIfNotExist(ctx, obj any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateIfNotExist", reflect.TypeOf((*MockClient)(nil).CreateIfNotExist), ctx, obj)
+}
+
+// CreateOrPatch mocks base method.
+func (m *MockClient) CreateOrPatch(ctx context.Context, obj client.Object, f func() error) (controllerutil.OperationResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateOrPatch", ctx, obj, f)
+	ret0, _ := ret[0].(controllerutil.OperationResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateOrPatch indicates an expected call of CreateOrPatch.
+func (mr *MockClientMockRecorder) CreateOrPatch(ctx, obj, f any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateOrPatch", reflect.TypeOf((*MockClient)(nil).CreateOrPatch), ctx, obj, f)
+}
+
+// CreateOrPatchWithJsonMerge mocks base method.
+func (m *MockClient) CreateOrPatchWithJsonMerge(ctx context.Context, obj client.Object, f func() error) (controllerutil.OperationResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateOrPatchWithJsonMerge", ctx, obj, f)
+	ret0, _ := ret[0].(controllerutil.OperationResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateOrPatchWithJsonMerge indicates an expected call of CreateOrPatchWithJsonMerge.
+func (mr *MockClientMockRecorder) CreateOrPatchWithJsonMerge(ctx, obj, f any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateOrPatchWithJsonMerge", reflect.TypeOf((*MockClient)(nil).CreateOrPatchWithJsonMerge), ctx, obj, f)
+}
+
+// CreateOrUpdate mocks base method.
+func (m *MockClient) CreateOrUpdate(ctx context.Context, obj client.Object, f func() error) (controllerutil.OperationResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateOrUpdate", ctx, obj, f)
+	ret0, _ := ret[0].(controllerutil.OperationResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateOrUpdate indicates an expected call of CreateOrUpdate.
+func (mr *MockClientMockRecorder) CreateOrUpdate(ctx, obj, f any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateOrUpdate", reflect.TypeOf((*MockClient)(nil).CreateOrUpdate), ctx, obj, f)
+}
+
+// Delete mocks base method.
+func (m *MockClient) Delete(ctx context.Context, obj client.Object, opts ...client.DeleteOption) error {
+	m.ctrl.T.Helper()
+	varargs := []any{ctx, obj}
+	for _, a := range opts {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "Delete", varargs...)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Delete indicates an expected call of Delete.
+func (mr *MockClientMockRecorder) Delete(ctx, obj any, opts ...any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]any{ctx, obj}, opts...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockClient)(nil).Delete), varargs...)
+}
+
+// DeleteAllOf mocks base method.
+func (m *MockClient) DeleteAllOf(ctx context.Context, obj client.Object, opts ...client.DeleteAllOfOption) error {
+	m.ctrl.T.Helper()
+	varargs := []any{ctx, obj}
+	for _, a := range opts {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "DeleteAllOf", varargs...)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteAllOf indicates an expected call of DeleteAllOf.
+func (mr *MockClientMockRecorder) DeleteAllOf(ctx, obj any, opts ...any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]any{ctx, obj}, opts...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteAllOf", reflect.TypeOf((*MockClient)(nil).DeleteAllOf), varargs...)
+}
+
+// DeleteIfExists mocks base method.
+func (m *MockClient) DeleteIfExists(ctx context.Context, obj client.Object) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteIfExists", ctx, obj)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteIfExists indicates an expected call of DeleteIfExists.
+func (mr *MockClientMockRecorder) DeleteIfExists(ctx, obj any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteIfExists", reflect.TypeOf((*MockClient)(nil).DeleteIfExists), ctx, obj)
+}
+
+// EnsureFinalizer mocks base method.
+func (m *MockClient) EnsureFinalizer(ctx context.Context, obj client.Object, finalizer string) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "EnsureFinalizer", ctx, obj, finalizer)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// EnsureFinalizer indicates an expected call of EnsureFinalizer.
+func (mr *MockClientMockRecorder) EnsureFinalizer(ctx, obj, finalizer any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EnsureFinalizer", reflect.TypeOf((*MockClient)(nil).EnsureFinalizer), ctx, obj, finalizer)
+}
+
+// Get mocks base method.
+func (m *MockClient) Get(ctx context.Context, key client.ObjectKey, obj client.Object, opts ...client.GetOption) error {
+	m.ctrl.T.Helper()
+	varargs := []any{ctx, key, obj}
+	for _, a := range opts {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "Get", varargs...)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Get indicates an expected call of Get.
+func (mr *MockClientMockRecorder) Get(ctx, key, obj any, opts ...any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]any{ctx, key, obj}, opts...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockClient)(nil).Get), varargs...)
+}
+
+// GetAndPatch mocks base method.
+func (m *MockClient) GetAndPatch(ctx context.Context, obj client.Object, f func() error) (controllerutil.OperationResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAndPatch", ctx, obj, f)
+	ret0, _ := ret[0].(controllerutil.OperationResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAndPatch indicates an expected call of GetAndPatch.
+func (mr *MockClientMockRecorder) GetAndPatch(ctx, obj, f any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAndPatch", reflect.TypeOf((*MockClient)(nil).GetAndPatch), ctx, obj, f)
+}
+
+// GetAndUpdate mocks base method.
+func (m *MockClient) GetAndUpdate(ctx context.Context, obj client.Object, f func() error) (controllerutil.OperationResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAndUpdate", ctx, obj, f)
+	ret0, _ := ret[0].(controllerutil.OperationResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAndUpdate indicates an expected call of GetAndUpdate.
+func (mr *MockClientMockRecorder) GetAndUpdate(ctx, obj, f any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAndUpdate", reflect.TypeOf((*MockClient)(nil).GetAndUpdate), ctx, obj, f)
+}
+
+// GetConfigMapFieldJSONUnmarshal mocks base method.
+func (m *MockClient) GetConfigMapFieldJSONUnmarshal(ctx context.Context, namespace, name, field string, obj any) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetConfigMapFieldJSONUnmarshal", ctx, namespace, name, field, obj)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// GetConfigMapFieldJSONUnmarshal indicates an expected call of GetConfigMapFieldJSONUnmarshal.
+func (mr *MockClientMockRecorder) GetConfigMapFieldJSONUnmarshal(ctx, namespace, name, field, obj any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetConfigMapFieldJSONUnmarshal", reflect.TypeOf((*MockClient)(nil).GetConfigMapFieldJSONUnmarshal), ctx, namespace, name, field, obj)
+}
+
+// GetConfigMapFieldYamlUnmarshal mocks base method.
+func (m *MockClient) GetConfigMapFieldYamlUnmarshal(ctx context.Context, namespace, name, field string, obj any) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetConfigMapFieldYamlUnmarshal", ctx, namespace, name, field, obj)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// GetConfigMapFieldYamlUnmarshal indicates an expected call of GetConfigMapFieldYamlUnmarshal.
+func (mr *MockClientMockRecorder) GetConfigMapFieldYamlUnmarshal(ctx, namespace, name, field, obj any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetConfigMapFieldYamlUnmarshal", reflect.TypeOf((*MockClient)(nil).GetConfigMapFieldYamlUnmarshal), ctx, namespace, name, field, obj)
+}
+
+// GetDecodedSecretField mocks base method.
+func (m *MockClient) GetDecodedSecretField(ctx context.Context, namespace, name, field string) ([]byte, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetDecodedSecretField", ctx, namespace, name, field)
+	ret0, _ := ret[0].([]byte)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetDecodedSecretField indicates an expected call of GetDecodedSecretField.
+func (mr *MockClientMockRecorder) GetDecodedSecretField(ctx, namespace, name, field any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDecodedSecretField", reflect.TypeOf((*MockClient)(nil).GetDecodedSecretField), ctx, namespace, name, field)
+}
+
+// GetGardenerKubeconfig mocks base method.
+func (m *MockClient) GetGardenerKubeconfig(ctx context.Context, namespace, secretName string) ([]byte, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetGardenerKubeconfig", ctx, namespace, secretName)
+	ret0, _ := ret[0].([]byte)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetGardenerKubeconfig indicates an expected call of GetGardenerKubeconfig.
+func (mr *MockClientMockRecorder) GetGardenerKubeconfig(ctx, namespace, secretName any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetGardenerKubeconfig", reflect.TypeOf((*MockClient)(nil).GetGardenerKubeconfig), ctx, namespace, secretName)
+}
+
+// GetNonEmptyConfigMapField mocks base method.
+func (m *MockClient) GetNonEmptyConfigMapField(ctx context.Context, namespace, name, field string) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetNonEmptyConfigMapField", ctx, namespace, name, field)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetNonEmptyConfigMapField indicates an expected call of GetNonEmptyConfigMapField.
+func (mr *MockClientMockRecorder) GetNonEmptyConfigMapField(ctx, namespace, name, field any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetNonEmptyConfigMapField", reflect.TypeOf((*MockClient)(nil).GetNonEmptyConfigMapField), ctx, namespace, name, field)
+}
+
+// GetNonEmptySecretField mocks base method.
+func (m *MockClient) GetNonEmptySecretField(ctx context.Context, namespace, name, field string) ([]byte, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetNonEmptySecretField", ctx, namespace, name, field)
+	ret0, _ := ret[0].([]byte)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetNonEmptySecretField indicates an expected call of GetNonEmptySecretField.
+func (mr *MockClientMockRecorder) GetNonEmptySecretField(ctx, namespace, name, field any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetNonEmptySecretField", reflect.TypeOf((*MockClient)(nil).GetNonEmptySecretField), ctx, namespace, name, field)
+}
+
+// GroupVersionKindFor mocks base method.
+func (m *MockClient) GroupVersionKindFor(obj runtime.Object) (schema.GroupVersionKind, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GroupVersionKindFor", obj)
+	ret0, _ := ret[0].(schema.GroupVersionKind)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GroupVersionKindFor indicates an expected call of GroupVersionKindFor.
+func (mr *MockClientMockRecorder) GroupVersionKindFor(obj any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GroupVersionKindFor", reflect.TypeOf((*MockClient)(nil).GroupVersionKindFor), obj)
+}
+
+// IsObjectNamespaced mocks base method.
+func (m *MockClient) IsObjectNamespaced(obj runtime.Object) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IsObjectNamespaced", obj)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// IsObjectNamespaced indicates an expected call of IsObjectNamespaced.
+func (mr *MockClientMockRecorder) IsObjectNamespaced(obj any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IsObjectNamespaced", reflect.TypeOf((*MockClient)(nil).IsObjectNamespaced), obj)
+}
+
+// List mocks base method.
+func (m *MockClient) List(ctx context.Context, list client.ObjectList, opts ...client.ListOption) error {
+	m.ctrl.T.Helper()
+	varargs := []any{ctx, list}
+	for _, a := range opts {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "List", varargs...)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// List indicates an expected call of List.
+func (mr *MockClientMockRecorder) List(ctx, list any, opts ...any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]any{ctx, list}, opts...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "List", reflect.TypeOf((*MockClient)(nil).List), varargs...)
+}
+
+// ListAllPages mocks base method.
+func (m *MockClient) ListAllPages(ctx context.Context, list client.ObjectList, opts ...client.ListOption) error {
+	m.ctrl.T.Helper()
+	varargs := []any{ctx, list}
+	for _, a := range opts {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "ListAllPages", varargs...)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ListAllPages indicates an expected call of ListAllPages.
+func (mr *MockClientMockRecorder) ListAllPages(ctx, list any, opts ...any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]any{ctx, list}, opts...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListAllPages", reflect.TypeOf((*MockClient)(nil).ListAllPages), varargs...)
+}
+
+// Patch mocks base method.
+func (m *MockClient) Patch(ctx context.Context, obj client.Object, patch client.Patch, opts ...client.PatchOption) error {
+	m.ctrl.T.Helper()
+	varargs := []any{ctx, obj, patch}
+	for _, a := range opts {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "Patch", varargs...)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Patch indicates an expected call of Patch.
+func (mr *MockClientMockRecorder) Patch(ctx, obj, patch any, opts ...any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]any{ctx, obj, patch}, opts...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Patch", reflect.TypeOf((*MockClient)(nil).Patch), varargs...)
+}
+
+// PatchStatus mocks base method.
+func (m *MockClient) PatchStatus(ctx context.Context, obj client.Object, patch client.Patch) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PatchStatus", ctx, obj, patch)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// PatchStatus indicates an expected call of PatchStatus.
+func (mr *MockClientMockRecorder) PatchStatus(ctx, obj, patch any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PatchStatus", reflect.TypeOf((*MockClient)(nil).PatchStatus), ctx, obj, patch)
+}
+
+// RESTMapper mocks base method.
+func (m *MockClient) RESTMapper() meta.RESTMapper {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RESTMapper")
+	ret0, _ := ret[0].(meta.RESTMapper)
+	return ret0
+}
+
+// RESTMapper indicates an expected call of RESTMapper.
+func (mr *MockClientMockRecorder) RESTMapper() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RESTMapper", reflect.TypeOf((*MockClient)(nil).RESTMapper))
+}
+
+// RemoveFinalizer mocks base method.
+func (m *MockClient) RemoveFinalizer(ctx context.Context, obj client.Object, finalizer string) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RemoveFinalizer", ctx, obj, finalizer)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RemoveFinalizer indicates an expected call of RemoveFinalizer.
+func (mr *MockClientMockRecorder) RemoveFinalizer(ctx, obj, finalizer any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoveFinalizer", reflect.TypeOf((*MockClient)(nil).RemoveFinalizer), ctx, obj, finalizer)
+}
+
+// Scheme mocks base method.
+func (m *MockClient) Scheme() *runtime.Scheme {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Scheme")
+	ret0, _ := ret[0].(*runtime.Scheme)
+	return ret0
+}
+
+// Scheme indicates an expected call of Scheme.
+func (mr *MockClientMockRecorder) Scheme() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Scheme", reflect.TypeOf((*MockClient)(nil).Scheme))
+}
+
+// ServerSideApply mocks base method.
+func (m *MockClient) ServerSideApply(ctx context.Context, obj client.Object, fieldManager string, force bool) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ServerSideApply", ctx, obj, fieldManager, force)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ServerSideApply indicates an expected call of ServerSideApply.
+func (mr *MockClientMockRecorder) ServerSideApply(ctx, obj, fieldManager, force any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ServerSideApply", reflect.TypeOf((*MockClient)(nil).ServerSideApply), ctx, obj, fieldManager, force)
+}
+
+// Status mocks base method.
+func (m *MockClient) Status() client.SubResourceWriter {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Status")
+	ret0, _ := ret[0].(client.SubResourceWriter)
+	return ret0
+}
+
+// Status indicates an expected call of Status.
+func (mr *MockClientMockRecorder) Status() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Status", reflect.TypeOf((*MockClient)(nil).Status))
+}
+
+// SubResource mocks base method.
+func (m *MockClient) SubResource(subResource string) client.SubResourceClient {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SubResource", subResource)
+	ret0, _ := ret[0].(client.SubResourceClient)
+	return ret0
+}
+
+// SubResource indicates an expected call of SubResource.
+func (mr *MockClientMockRecorder) SubResource(subResource any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SubResource", reflect.TypeOf((*MockClient)(nil).SubResource), subResource)
+}
+
+// Update mocks base method.
+func (m *MockClient) Update(ctx context.Context, obj client.Object, opts ...client.UpdateOption) error {
+	m.ctrl.T.Helper()
+	varargs := []any{ctx, obj}
+	for _, a := range opts {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "Update", varargs...)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Update indicates an expected call of Update.
+func (mr *MockClientMockRecorder) Update(ctx, obj any, opts ...any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]any{ctx, obj}, opts...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*MockClient)(nil).Update), varargs...)
+}
+
+// UpdateStatus mocks base method.
+func (m *MockClient) UpdateStatus(ctx context.Context, obj client.Object) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateStatus", ctx, obj)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateStatus indicates an expected call of UpdateStatus.
+func (mr *MockClientMockRecorder) UpdateStatus(ctx, obj any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateStatus", reflect.TypeOf((*MockClient)(nil).UpdateStatus), ctx, obj)
+}
@@ -0,0 +1,139 @@
+package deadletter
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/reborn1867/k8s-resource-tracer/pkg/backend"
+	"github.com/reborn1867/k8s-resource-tracer/pkg/git"
+	"github.com/reborn1867/k8s-resource-tracer/pkg/metrics"
+)
+
+const DefaultMaxRecords = 10000
+
+// GitChange is the file a change would have been committed as
+type GitChange struct {
+	Subpath      string        `json:"subpath"`
+	User         string        `json:"user"`
+	FieldManager string        `json:"fieldManager"`
+	Subject      string        `json:"subject,omitempty"`
+	Operation    string        `json:"operation,omitempty"`
+	Data         []byte        `json:"data"`
+	Trailers     []git.Trailer `json:"trailers,omitempty"`
+}
+
+// Record is a change no destination could store, with everything needed to store it again
+type Record struct {
+	Change   backend.Change `json:"change"`
+	Git      *GitChange     `json:"git,omitempty"`
+	Errors   []string       `json:"errors"`
+	FailedAt time.Time      `json:"failedAt"`
+}
+
+// Store keeps records as json files in a directory, bounded to MaxRecords files
+type Store struct {
+	dir        string
+	maxRecords int
+
+	mu    sync.Mutex
+	depth int
+}
+
+func NewStore(dir string, maxRecords int) (*Store, error) {
+	if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+		return nil, fmt.Errorf("failed to create dead letter directory, path: %s, err: %s", dir, err)
+	}
+	if maxRecords <= 0 {
+		maxRecords = DefaultMaxRecords
+	}
+
+	s := &Store{dir: dir, maxRecords: maxRecords}
+	names, err := s.List()
+	if err != nil {
+		return nil, err
+	}
+	s.depth = len(names)
+	metrics.DeadLetterDepth.Set(float64(s.depth))
+
+	return s, nil
+}
+
+// Put persists the record, it fails once the store holds MaxRecords records
+func (s *Store) Put(rec Record) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.depth >= s.maxRecords {
+		return fmt.Errorf("dead letter store is full, %d records in %s", s.depth, s.dir)
+	}
+
+	data, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+
+	name := fmt.Sprintf("%d-%s.json", rec.FailedAt.UnixNano(), strings.NewReplacer("/", "_").Replace(rec.Change.Name))
+	tmp := filepath.Join(s.dir, "."+name)
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("failed to write dead letter record, path: %s, err: %s", tmp, err)
+	}
+	if err := os.Rename(tmp, filepath.Join(s.dir, name)); err != nil {
+		return err
+	}
+
+	s.depth++
+	metrics.DeadLetterDepth.Set(float64(s.depth))
+
+	return nil
+}
+
+// List returns the names of the records, oldest first
+func (s *Store) List() ([]string, error) {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list dead letter records, path: %s, err: %s", s.dir, err)
+	}
+
+	var names []string
+	for _, e := range entries {
+		if e.IsDir() || strings.HasPrefix(e.Name(), ".") || !strings.HasSuffix(e.Name(), ".json") {
+			continue
+		}
+		names = append(names, e.Name())
+	}
+	sort.Strings(names)
+
+	return names, nil
+}
+
+func (s *Store) Get(name string) (Record, error) {
+	rec := Record{}
+	data, err := os.ReadFile(filepath.Join(s.dir, name))
+	if err != nil {
+		return rec, err
+	}
+
+	err = json.Unmarshal(data, &rec)
+	return rec, err
+}
+
+// Remove deletes a record once it has been stored again
+func (s *Store) Remove(name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := os.Remove(filepath.Join(s.dir, name)); err != nil {
+		return err
+	}
+
+	s.depth--
+	metrics.DeadLetterDepth.Set(float64(s.depth))
+
+	return nil
+}
@@ -0,0 +1,81 @@
+package git
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	"github.com/go-git/go-git/v5/plumbing/transport/http"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/reborn1867/k8s-resource-tracer/pkg/common"
+)
+
+// keys read from the secret of SecretAuthProvider
+const (
+	secretUsernameKey = "username"
+	secretPasswordKey = "password"
+	secretTokenKey    = "token"
+)
+
+// AuthProvider returns the auth of the next git operation
+type AuthProvider func() (transport.AuthMethod, error)
+
+// ProvidedAuth is an auth method whose credentials are asked from its provider right before every clone, fetch
+// and push, so that short-lived tokens are refreshed during the lifetime of the tracer
+type ProvidedAuth struct {
+	provider AuthProvider
+}
+
+func NewProvidedAuth(provider AuthProvider) *ProvidedAuth {
+	return &ProvidedAuth{provider: provider}
+}
+
+func (a *ProvidedAuth) Name() string {
+	return "provided"
+}
+
+func (a *ProvidedAuth) String() string {
+	return "provided auth"
+}
+
+// resolveAuth returns the current credentials of a ProvidedAuth and any other auth as it is
+func resolveAuth(auth transport.AuthMethod) (transport.AuthMethod, error) {
+	provided, ok := auth.(*ProvidedAuth)
+	if !ok {
+		return auth, nil
+	}
+
+	resolved, err := provided.provider()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get git credentials, err: %s", err)
+	}
+	return resolved, nil
+}
+
+// SecretAuthProvider reads basic auth from the secret namespace/name on every call, either a token under the key
+// token, sent as the password, or a username and password. Rotating the secret rotates the credentials.
+func SecretAuthProvider(c common.Client, namespace, name string) AuthProvider {
+	return func() (transport.AuthMethod, error) {
+		secret := &corev1.Secret{}
+		if err := c.Get(context.TODO(), types.NamespacedName{Namespace: namespace, Name: name}, secret); err != nil {
+			return nil, fmt.Errorf("failed to get secret, namespace: %s, name: %s, err: %s", namespace, name, err)
+		}
+
+		username := string(secret.Data[secretUsernameKey])
+		if token := secret.Data[secretTokenKey]; len(token) > 0 {
+			// git hosts expect access tokens as the password of basic auth, the user name is mostly ignored
+			if username == "" {
+				username = "x-access-token"
+			}
+			return &http.BasicAuth{Username: username, Password: string(token)}, nil
+		}
+
+		password := string(secret.Data[secretPasswordKey])
+		if username == "" && password == "" {
+			return nil, fmt.Errorf("neither %s nor %s/%s is set in secret %s/%s", secretTokenKey, secretUsernameKey, secretPasswordKey, namespace, name)
+		}
+		return &http.BasicAuth{Username: username, Password: password}, nil
+	}
+}
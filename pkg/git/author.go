@@ -0,0 +1,111 @@
+package git
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	// userTrailer keeps the raw kubernetes user name in the commit message when it is mapped to another author
+	userTrailer = "Kubernetes-User"
+
+	// DefaultAuthorEmailDomain makes up the emails of authors without one, e.g. alice@k8s-resource-tracer.local
+	DefaultAuthorEmailDomain = "k8s-resource-tracer.local"
+)
+
+type Author struct {
+	Name  string `yaml:"name" json:"name"`
+	Email string `yaml:"email" json:"email"`
+}
+
+// AuthorMapping maps a kubernetes user name, e.g. system:serviceaccount:ns:name, to a commit author
+type AuthorMapping map[string]Author
+
+func LoadAuthorMapping(file string) (AuthorMapping, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read author mapping, path: %s, err: %s", file, err)
+	}
+
+	m := AuthorMapping{}
+	if err := yaml.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("failed to parse author mapping, path: %s, err: %s", file, err)
+	}
+
+	return m, nil
+}
+
+// Lookup returns the mapped author of the user, unmapped users are used as author name as is
+func (m AuthorMapping) Lookup(userInfo string) (Author, bool) {
+	author, ok := m[userInfo]
+	if !ok || author.Name == "" {
+		return Author{Name: userInfo}, false
+	}
+
+	return author, true
+}
+
+// Identity decides the author and committer of commits
+type Identity struct {
+	Authors AuthorMapping
+	// EmailDomain makes up the email of authors without one, empty means DefaultAuthorEmailDomain
+	EmailDomain string
+	// Committer is the identity pushing the commits, e.g. the tracer, empty means the author
+	Committer Author
+	// SignKey signs the commits with gpg, nil leaves them unsigned
+	SignKey *openpgp.Entity
+}
+
+// signatures returns the author and committer of a change made by the user, it reports whether the user is mapped
+func (id Identity) signatures(userInfo string) (*object.Signature, *object.Signature, bool) {
+	author, mapped := id.Authors.Lookup(userInfo)
+	if author.Email == "" {
+		author.Email = id.email(author.Name)
+	}
+
+	now := time.Now()
+	authorSig := &object.Signature{Name: author.Name, Email: author.Email, When: now}
+	if id.Committer.Name == "" {
+		return authorSig, nil, mapped
+	}
+
+	committer := id.Committer
+	if committer.Email == "" {
+		committer.Email = id.email(committer.Name)
+	}
+	return authorSig, &object.Signature{Name: committer.Name, Email: committer.Email, When: now}, mapped
+}
+
+// email returns name if it is an email already, otherwise name in EmailDomain with the characters not allowed in
+// the local part replaced, e.g. system:serviceaccount:ns:sa becomes system.serviceaccount.ns.sa@<domain>
+func (id Identity) email(name string) string {
+	if strings.Contains(name, "@") {
+		return name
+	}
+
+	domain := id.EmailDomain
+	if domain == "" {
+		domain = DefaultAuthorEmailDomain
+	}
+
+	local := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '.', r == '-', r == '_', r == '+':
+			return r
+		case r == ':':
+			return '.'
+		}
+		return '-'
+	}, name)
+	if local == "" {
+		local = "unknown"
+	}
+
+	return local + "@" + domain
+}
@@ -0,0 +1,81 @@
+package git
+
+import (
+	"fmt"
+
+	gg "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/utils/merkletrie"
+)
+
+// FileChange is a file which differs between two commits
+type FileChange struct {
+	Path   string `json:"path"`
+	Action string `json:"action"`
+}
+
+// DiffBaseline compares the tree of baseline, a tag, branch or commit, with the tree of HEAD
+func DiffBaseline(path, baseline string) ([]FileChange, error) {
+	r, err := gg.PlainOpen(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open repository, path: %s, err: %s", path, err)
+	}
+
+	from, err := resolveTree(r, baseline)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve baseline %s: %s", baseline, err)
+	}
+
+	to, err := resolveTree(r, "HEAD")
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve HEAD: %s", err)
+	}
+
+	changes, err := object.DiffTree(from, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compare trees: %s", err)
+	}
+
+	var out []FileChange
+	for _, c := range changes {
+		action, err := c.Action()
+		if err != nil {
+			return nil, err
+		}
+
+		name := c.To.Name
+		if action == merkletrie.Delete {
+			name = c.From.Name
+		}
+
+		out = append(out, FileChange{Path: name, Action: actionName(action)})
+	}
+
+	return out, nil
+}
+
+func resolveTree(r *gg.Repository, revision string) (*object.Tree, error) {
+	hash, err := r.ResolveRevision(plumbing.Revision(revision))
+	if err != nil {
+		return nil, err
+	}
+
+	commit, err := r.CommitObject(*hash)
+	if err != nil {
+		return nil, err
+	}
+
+	return commit.Tree()
+}
+
+func actionName(action merkletrie.Action) string {
+	switch action {
+	case merkletrie.Insert:
+		return "added"
+	case merkletrie.Delete:
+		return "deleted"
+	default:
+		return "modified"
+	}
+}
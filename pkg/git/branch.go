@@ -0,0 +1,49 @@
+package git
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/go-git/go-git/v5/plumbing"
+	"gopkg.in/yaml.v2"
+)
+
+// BranchMapping maps a cluster identifier, the cluster name or the kubernetes service host, to a branch
+type BranchMapping map[string]string
+
+func LoadBranchMapping(file string) (BranchMapping, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read branch mapping, path: %s, err: %s", file, err)
+	}
+
+	m := BranchMapping{}
+	if err := yaml.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("failed to parse branch mapping, path: %s, err: %s", file, err)
+	}
+
+	return m, nil
+}
+
+// Resolve returns the branch of the first identifier found in the mapping
+func (m BranchMapping) Resolve(ids ...string) (string, bool) {
+	for _, id := range ids {
+		if branch, ok := m[id]; ok && id != "" && branch != "" {
+			return branch, true
+		}
+	}
+
+	return "", false
+}
+
+// ValidateBranch checks that branch can be used as a git branch name
+func ValidateBranch(branch string) error {
+	if branch == "" {
+		return fmt.Errorf("empty branch name")
+	}
+	if err := plumbing.NewBranchReferenceName(branch).Validate(); err != nil {
+		return fmt.Errorf("invalid branch name %s: %s", branch, err)
+	}
+
+	return nil
+}
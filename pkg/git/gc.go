@@ -0,0 +1,82 @@
+package git
+
+import (
+	"fmt"
+	"io/fs"
+	"path/filepath"
+
+	gg "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/storer"
+)
+
+// GC packs the objects reachable from any ref into a single packfile and removes the loose objects not referenced
+// by the index, like git gc. It returns the size of the .git directory before and after. Objects written while it runs would be removed, so it
+// must not run concurrently with commits.
+func GC(path string) (int64, int64, error) {
+	before, err := dirSize(filepath.Join(path, gg.GitDirName))
+	if err != nil {
+		return 0, 0, err
+	}
+
+	r, err := gg.PlainOpen(path)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to open repository, path: %s, err: %s", path, err)
+	}
+
+	if err := r.RepackObjects(&gg.RepackConfig{}); err != nil {
+		return 0, 0, fmt.Errorf("failed to repack objects, path: %s, err: %s", path, err)
+	}
+
+	// staged blobs are only referenced by the index until they are committed
+	idx, err := r.Storer.Index()
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to read index, path: %s, err: %s", path, err)
+	}
+	staged := map[plumbing.Hash]bool{}
+	for _, e := range idx.Entries {
+		staged[e.Hash] = true
+	}
+
+	// every reachable object is part of the new pack now, the remaining loose objects are either duplicates,
+	// staged or unreachable
+	los, ok := r.Storer.(storer.LooseObjectStorer)
+	if !ok {
+		return 0, 0, gg.ErrLooseObjectsNotSupported
+	}
+	if err := los.ForEachObjectHash(func(h plumbing.Hash) error {
+		if staged[h] {
+			return nil
+		}
+		return los.DeleteLooseObject(h)
+	}); err != nil {
+		return 0, 0, fmt.Errorf("failed to remove loose objects, path: %s, err: %s", path, err)
+	}
+
+	after, err := dirSize(filepath.Join(path, gg.GitDirName))
+	if err != nil {
+		return 0, 0, err
+	}
+
+	return before, after, nil
+}
+
+func dirSize(dir string) (int64, error) {
+	var size int64
+	err := filepath.WalkDir(dir, func(_ string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		size += info.Size()
+		return nil
+	})
+
+	return size, err
+}
@@ -0,0 +1,50 @@
+package git
+
+import (
+	"testing"
+
+	gg "github.com/go-git/go-git/v5"
+	"github.com/go-logr/logr"
+)
+
+func TestGCKeepsStagedObjects(t *testing.T) {
+	path := initRepo(t)
+	logger := logr.Discard()
+
+	if err := CommitChange(path, "a.yaml", OperationCreate, "alice", "kubectl", "", []byte("a: 1\n"), nil, Identity{}, logger); err != nil {
+		t.Fatal(err)
+	}
+	if err := StageChange(path, "b.yaml", []byte("b: 1\n"), logger); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, _, err := GC(path); err != nil {
+		t.Fatal(err)
+	}
+
+	// the tree of the batch commit must only reference existing blobs
+	if err := CommitStaged(path, "alice", "batch", nil, Identity{}); err != nil {
+		t.Fatal(err)
+	}
+	r, err := gg.PlainOpen(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	head, err := r.Head()
+	if err != nil {
+		t.Fatal(err)
+	}
+	commit, err := r.CommitObject(head.Hash())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"a.yaml", "b.yaml"} {
+		f, err := commit.File(name)
+		if err != nil {
+			t.Fatalf("missing %s after gc: %s", name, err)
+		}
+		if _, err := f.Contents(); err != nil {
+			t.Errorf("failed to read blob of %s after gc: %s", name, err)
+		}
+	}
+}
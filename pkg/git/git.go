@@ -1,21 +1,32 @@
 package git
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 	"time"
 
+	"github.com/ProtonMail/go-crypto/openpgp"
 	gg "github.com/go-git/go-git/v5"
 	"github.com/go-git/go-git/v5/config"
 	"github.com/go-git/go-git/v5/plumbing"
-	"github.com/go-git/go-git/v5/plumbing/object"
 	"github.com/go-git/go-git/v5/plumbing/transport"
+	"github.com/go-git/go-git/v5/storage/memory"
 	"github.com/go-logr/logr"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/util/retry"
 )
 
-func Clone(url, path string, auth transport.AuthMethod) error {
-	_, err := gg.PlainClone(path, false, &gg.CloneOptions{
+func Clone(ctx context.Context, url, path string, auth transport.AuthMethod) error {
+	auth, err := resolveAuth(auth)
+	if err != nil {
+		return err
+	}
+
+	_, err = gg.PlainCloneContext(ctx, path, false, &gg.CloneOptions{
 		Auth: auth,
 		URL:  url,
 	})
@@ -23,7 +34,29 @@ func Clone(url, path string, auth transport.AuthMethod) error {
 	return err
 }
 
-func Pull(path, branch string) error {
+// ListRemote lists the references of the remote at url like git ls-remote, it only checks that the remote is
+// reachable with auth
+func ListRemote(ctx context.Context, url string, auth transport.AuthMethod) error {
+	auth, err := resolveAuth(auth)
+	if err != nil {
+		return err
+	}
+
+	remote := gg.NewRemote(memory.NewStorage(), &config.RemoteConfig{Name: "origin", URLs: []string{url}})
+	_, err = remote.ListContext(ctx, &gg.ListOptions{Auth: auth})
+	if errors.Is(err, transport.ErrEmptyRemoteRepository) {
+		return nil
+	}
+
+	return err
+}
+
+func Pull(ctx context.Context, path, branch string, auth transport.AuthMethod) error {
+	auth, err := resolveAuth(auth)
+	if err != nil {
+		return err
+	}
+
 	r, err := gg.PlainOpen(path)
 	if err != nil {
 		return err
@@ -34,9 +67,10 @@ func Pull(path, branch string) error {
 		return err
 	}
 
-	if err := w.Pull(&gg.PullOptions{
+	if err := w.PullContext(ctx, &gg.PullOptions{
 		RemoteName:    "origin",
 		ReferenceName: plumbing.NewBranchReferenceName(branch),
+		Auth:          auth,
 	}); err != nil && err != gg.NoErrAlreadyUpToDate {
 		return err
 	}
@@ -44,7 +78,12 @@ func Pull(path, branch string) error {
 	return nil
 }
 
-func Checkout(path, branchName string, logger logr.Logger) error {
+func Checkout(ctx context.Context, path, branchName string, auth transport.AuthMethod, logger logr.Logger) error {
+	auth, err := resolveAuth(auth)
+	if err != nil {
+		return err
+	}
+
 	r, err := gg.PlainOpen(path)
 	if err != nil {
 		return err
@@ -63,7 +102,7 @@ func Checkout(path, branchName string, logger logr.Logger) error {
 	}
 
 	mirrorRemoteBranchRefSpec := fmt.Sprintf("refs/heads/%s:refs/heads/%s", branchName, branchName)
-	if err := fetchOrigin(r, mirrorRemoteBranchRefSpec, logger); err != nil {
+	if err := fetchOrigin(ctx, r, mirrorRemoteBranchRefSpec, auth, logger); err != nil {
 		return err
 	}
 
@@ -73,7 +112,148 @@ func Checkout(path, branchName string, logger logr.Logger) error {
 	return nil
 }
 
-func CommitChange(path, subPath, userInfo, fieldManger string, data []byte, logger logr.Logger) error {
+// Operations of changes, they are the operations of admission requests
+const (
+	OperationCreate = "CREATE"
+	OperationUpdate = "UPDATE"
+	OperationDelete = "DELETE"
+)
+
+// operationTrailer names the operation of a change in its commit message
+const operationTrailer = "Operation"
+
+// CommitChange commits the change of the object at subPath made by operation, deletes remove the file and
+// anything else writes data to it. An empty operation is committed as an update.
+func CommitChange(path, subPath, operation, userInfo, fieldManger, subject string, data []byte, trailers []Trailer, identity Identity, logger logr.Logger) error {
+	if operation != "" {
+		trailers = append([]Trailer{{Key: operationTrailer, Value: operation}}, trailers...)
+	}
+	if operation == OperationDelete {
+		return RemoveChange(path, subPath, userInfo, fieldManger, subject, trailers, identity, logger)
+	}
+
+	if err := StageChange(path, subPath, data, logger); err != nil {
+		return err
+	}
+
+	r, err := gg.PlainOpen(path)
+	if err != nil {
+		return fmt.Errorf("failed to open repository, path: %s, err: %s", path, err)
+	}
+
+	wtree, err := r.Worktree()
+	if err != nil {
+		return fmt.Errorf("failed to create work tree: %s, err: %s", path, err)
+	}
+
+	author, committer, mapped := identity.signatures(userInfo)
+	if mapped {
+		trailers = append([]Trailer{{Key: userTrailer, Value: userInfo}}, trailers...)
+	}
+	if subject == "" {
+		verb := "changed"
+		if operation == OperationCreate {
+			verb = "created"
+		}
+		subject = fmt.Sprintf("%s by %s, field manager: %s", verb, userInfo, fieldManger)
+	}
+	message := buildMessage(subject, trailers)
+
+	commit, err := wtree.Commit(message, &gg.CommitOptions{
+		Author:    author,
+		Committer: committer,
+		SignKey:   identity.SignKey,
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = r.CommitObject(commit)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// RemoveChange deletes subPath from the repository and commits the removal, nothing is committed if the file
+// was never stored
+func RemoveChange(path, subPath, userInfo, fieldManger, subject string, trailers []Trailer, identity Identity, logger logr.Logger) error {
+	r, err := gg.PlainOpen(path)
+	if err != nil {
+		return fmt.Errorf("failed to open repository, path: %s, err: %s", path, err)
+	}
+
+	wtree, err := r.Worktree()
+	if err != nil {
+		return fmt.Errorf("failed to create work tree: %s, err: %s", path, err)
+	}
+
+	targetFile := filepath.Join(path, subPath)
+	if _, err := os.Stat(targetFile); os.IsNotExist(err) {
+		logger.V(1).Info("deleted object was never stored, skipping", "file", targetFile)
+		return nil
+	}
+
+	if _, err = wtree.Remove(subPath); err != nil {
+		return fmt.Errorf("failed to remove file, path: %s, err: %s", subPath, err)
+	}
+
+	logger.V(1).Info("git rm successfully", "file", targetFile)
+
+	author, committer, mapped := identity.signatures(userInfo)
+	if mapped {
+		trailers = append([]Trailer{{Key: userTrailer, Value: userInfo}}, trailers...)
+	}
+	if subject == "" {
+		subject = fmt.Sprintf("deleted by %s, field manager: %s", userInfo, fieldManger)
+	}
+	message := buildMessage(subject, trailers)
+
+	// go-git refuses to commit an empty index, which removing the last file leaves
+	_, err = wtree.Commit(message, &gg.CommitOptions{
+		AllowEmptyCommits: true,
+		Author:            author,
+		Committer:         committer,
+		SignKey:           identity.SignKey,
+	})
+
+	return err
+}
+
+// CommitTouch records that the object at subPath was admitted without changes as an empty commit
+func CommitTouch(path, subPath, userInfo, fieldManger, subject string, trailers []Trailer, identity Identity) error {
+	r, err := gg.PlainOpen(path)
+	if err != nil {
+		return fmt.Errorf("failed to open repository, path: %s, err: %s", path, err)
+	}
+
+	wtree, err := r.Worktree()
+	if err != nil {
+		return fmt.Errorf("failed to create work tree: %s, err: %s", path, err)
+	}
+
+	author, committer, mapped := identity.signatures(userInfo)
+	if mapped {
+		trailers = append([]Trailer{{Key: userTrailer, Value: userInfo}}, trailers...)
+	}
+	if subject == "" {
+		subject = fmt.Sprintf("touched %s by %s, field manager: %s", subPath, userInfo, fieldManger)
+	}
+	message := buildMessage(subject, trailers)
+
+	_, err = wtree.Commit(message, &gg.CommitOptions{
+		AllowEmptyCommits: true,
+		Author:            author,
+		Committer:         committer,
+		SignKey:           identity.SignKey,
+	})
+
+	return err
+}
+
+// StageChange writes data to subPath and adds it to the index without committing, see CommitStaged
+func StageChange(path, subPath string, data []byte, logger logr.Logger) error {
 	r, err := gg.PlainOpen(path)
 	if err != nil {
 		return fmt.Errorf("failed to open repository, path: %s, err: %s", path, err)
@@ -100,36 +280,400 @@ func CommitChange(path, subPath, userInfo, fieldManger string, data []byte, logg
 
 	logger.V(1).Info("git add successfully", "file", targetFile)
 
-	commit, err := wtree.Commit(fmt.Sprintf("changed by %s, field manager: %s", userInfo, fieldManger), &gg.CommitOptions{
-		Author: &object.Signature{
-			Name: userInfo,
-			When: time.Now(),
-		},
+	return nil
+}
+
+// StageRemoval removes subPath from the work tree and the index without committing, see CommitStaged
+func StageRemoval(path, subPath string, logger logr.Logger) error {
+	r, err := gg.PlainOpen(path)
+	if err != nil {
+		return fmt.Errorf("failed to open repository, path: %s, err: %s", path, err)
+	}
+
+	wtree, err := r.Worktree()
+	if err != nil {
+		return fmt.Errorf("failed to create work tree: %s, err: %s", path, err)
+	}
+
+	targetFile := filepath.Join(path, subPath)
+	if _, err := os.Stat(targetFile); os.IsNotExist(err) {
+		logger.V(1).Info("deleted object was never stored, skipping", "file", targetFile)
+		return nil
+	}
+
+	if _, err = wtree.Remove(subPath); err != nil {
+		return fmt.Errorf("failed to remove file, path: %s, err: %s", subPath, err)
+	}
+
+	logger.V(1).Info("git rm successfully", "file", targetFile)
+
+	return nil
+}
+
+// CommitStaged commits the index as it is, message is the full commit message without trailers
+func CommitStaged(path, userInfo, message string, trailers []Trailer, identity Identity) error {
+	r, err := gg.PlainOpen(path)
+	if err != nil {
+		return fmt.Errorf("failed to open repository, path: %s, err: %s", path, err)
+	}
+
+	wtree, err := r.Worktree()
+	if err != nil {
+		return fmt.Errorf("failed to create work tree: %s, err: %s", path, err)
+	}
+
+	author, committer, mapped := identity.signatures(userInfo)
+	if mapped {
+		trailers = append([]Trailer{{Key: userTrailer, Value: userInfo}}, trailers...)
+	}
+
+	// a batch of touches and removals of the last files leaves nothing or an empty index to commit
+	_, err = wtree.Commit(buildMessage(message, trailers), &gg.CommitOptions{
+		AllowEmptyCommits: true,
+		Author:            author,
+		Committer:         committer,
+		SignKey:           identity.SignKey,
 	})
+
+	return err
+}
+
+// PushOptions tunes the retries of PushToRemote
+type PushOptions struct {
+	// Backoff between attempts, Steps is the number of attempts
+	Backoff wait.Backoff
+	// Mirrors are pushed after the origin, whether or not pushing to the origin succeeded
+	Mirrors []Remote
+	// SignKey signs the local commits replayed on top of the remote when it has advanced, nil leaves them unsigned
+	SignKey *openpgp.Entity
+}
+
+// DefaultPushOptions keep the retries well below the timeout of admission webhooks
+var DefaultPushOptions = PushOptions{
+	Backoff: wait.Backoff{
+		Steps:    3,
+		Duration: 500 * time.Millisecond,
+		Factor:   2,
+		Jitter:   0.5,
+	},
+}
+
+// PushToRemote pushes the checked out branch, transient errors are retried and when the remote has advanced the
+// local commits are rebased on it before retrying. Authentication errors are not retried. The branch is mirrored
+// to opts.Mirrors afterwards.
+func PushToRemote(ctx context.Context, path string, auth transport.AuthMethod, opts PushOptions, logger logr.Logger) error {
+	auth, err := resolveAuth(auth)
 	if err != nil {
 		return err
 	}
 
-	_, err = r.CommitObject(commit)
+	r, err := gg.PlainOpen(path)
 	if err != nil {
 		return err
 	}
 
-	return nil
+	head, err := r.Head()
+	if err != nil {
+		return fmt.Errorf("failed to get HEAD, path: %s, err: %s", path, err)
+	}
+
+	backoff := opts.Backoff
+	if backoff.Steps < 1 {
+		backoff.Steps = 1
+	}
+
+	// only the checked out branch is pushed, other local branches like a review branch are pushed on their own
+	refSpec := config.RefSpec(fmt.Sprintf("%s:%s", head.Name(), head.Name()))
+	attempt := 0
+	err = retry.OnError(backoff, retriablePush, func() error {
+		attempt++
+		err := r.PushContext(ctx, &gg.PushOptions{
+			Auth:     auth,
+			RefSpecs: []config.RefSpec{refSpec},
+		})
+		if err == nil || err == gg.NoErrAlreadyUpToDate {
+			return nil
+		}
+		logger.Info("failed to push to remote", "attempt", attempt, "reason", err.Error())
+
+		if isNonFastForward(err) {
+			if rebaseErr := rebaseOnRemote(ctx, r, path, head.Name(), auth, opts.SignKey, logger); rebaseErr != nil {
+				return fmt.Errorf("remote has advanced and rebasing failed, err: %s", rebaseErr)
+			}
+		}
+
+		return err
+	})
+
+	if len(opts.Mirrors) == 0 {
+		return err
+	}
+
+	// a mirror error alone is returned as *MirrorError, the changes did reach the origin then
+	mirrorErr := pushMirrors(ctx, r, head.Name(), opts.Mirrors, auth, backoff, logger)
+	if err == nil {
+		return mirrorErr
+	}
+	if mirrorErr != nil {
+		return errors.Join(err, mirrorErr)
+	}
+	return err
+}
+
+// isNonFastForward reports whether the push was rejected because the remote has advanced, go-git doesn't wrap
+// ErrNonFastForwardUpdate when it returns it from a push
+func isNonFastForward(err error) bool {
+	return err == gg.ErrForceNeeded || strings.HasPrefix(err.Error(), gg.ErrNonFastForwardUpdate.Error())
+}
+
+func retriablePush(err error) bool {
+	return !errors.Is(err, transport.ErrAuthenticationRequired) && !errors.Is(err, transport.ErrAuthorizationFailed) &&
+		!errors.Is(err, context.DeadlineExceeded) && !errors.Is(err, context.Canceled)
+}
+
+// IsRepository reports whether path holds a git repository, e.g. one cloned by a previous run of the container
+func IsRepository(path string) bool {
+	_, err := gg.PlainOpen(path)
+	return err == nil
+}
+
+// pendingAuthor authors the changes left by an interrupted run, their users are not known anymore
+const pendingAuthor = "k8s-resource-tracer"
+
+// CommitPending commits changes left in the working tree by an interrupted run, it reports whether a commit was made
+func CommitPending(path string, identity Identity, logger logr.Logger) (bool, error) {
+	r, err := gg.PlainOpen(path)
+	if err != nil {
+		return false, fmt.Errorf("failed to open repository, path: %s, err: %s", path, err)
+	}
+
+	wtree, err := r.Worktree()
+	if err != nil {
+		return false, fmt.Errorf("failed to create work tree: %s, err: %s", path, err)
+	}
+
+	status, err := wtree.Status()
+	if err != nil {
+		return false, fmt.Errorf("failed to get status of work tree: %s, err: %s", path, err)
+	}
+
+	if status.IsClean() {
+		return false, nil
+	}
+
+	if err := wtree.AddWithOptions(&gg.AddOptions{All: true}); err != nil {
+		return false, fmt.Errorf("failed to add pending changes, path: %s, err: %s", path, err)
+	}
+
+	author, committer, _ := identity.signatures(pendingAuthor)
+	if _, err := wtree.Commit("pending changes of an interrupted run", &gg.CommitOptions{
+		Author:    author,
+		Committer: committer,
+		SignKey:   identity.SignKey,
+	}); err != nil {
+		return false, err
+	}
+
+	logger.Info("committed pending changes", "path", path, "files", len(status))
+
+	return true, nil
 }
 
-func PushToRemote(path string, auth transport.AuthMethod) error {
+func CurrentBranch(path string) (string, error) {
 	r, err := gg.PlainOpen(path)
+	if err != nil {
+		return "", err
+	}
+
+	head, err := r.Head()
+	if err != nil {
+		return "", fmt.Errorf("failed to get HEAD, path: %s, err: %s", path, err)
+	}
+
+	return head.Name().Short(), nil
+}
+
+// CheckoutBranch checks out the local branch, creating it from the remote branch of the same name or from HEAD
+// if the remote doesn't have it either
+func CheckoutBranch(ctx context.Context, path, branch string, auth transport.AuthMethod, logger logr.Logger) error {
+	auth, err := resolveAuth(auth)
 	if err != nil {
 		return err
 	}
 
-	return r.Push(&gg.PushOptions{
-		Auth: auth,
+	r, err := gg.PlainOpen(path)
+	if err != nil {
+		return err
+	}
+
+	w, err := r.Worktree()
+	if err != nil {
+		return err
+	}
+
+	refName := plumbing.NewBranchReferenceName(branch)
+	if _, err := r.Reference(refName, false); err == nil {
+		return w.Checkout(&gg.CheckoutOptions{Branch: refName})
+	} else if err != plumbing.ErrReferenceNotFound {
+		return err
+	}
+
+	remoteName := plumbing.NewRemoteReferenceName("origin", branch)
+	if err := fetchOrigin(ctx, r, fmt.Sprintf("+%s:%s", refName, remoteName), auth, logger); err != nil {
+		return err
+	}
+
+	opts := gg.CheckoutOptions{Branch: refName, Create: true}
+	if remote, err := r.Reference(remoteName, true); err == nil {
+		opts.Hash = remote.Hash()
+	}
+
+	return w.Checkout(&opts)
+}
+
+// SwitchBranch checks out the local branch, creating it from HEAD if it doesn't exist yet
+func SwitchBranch(path, branch string) error {
+	r, err := gg.PlainOpen(path)
+	if err != nil {
+		return err
+	}
+
+	w, err := r.Worktree()
+	if err != nil {
+		return err
+	}
+
+	refName := plumbing.NewBranchReferenceName(branch)
+	_, err = r.Reference(refName, false)
+	if err != nil && err != plumbing.ErrReferenceNotFound {
+		return err
+	}
+
+	return w.Checkout(&gg.CheckoutOptions{
+		Branch: refName,
+		Create: err == plumbing.ErrReferenceNotFound,
 	})
 }
 
-func fetchOrigin(repo *gg.Repository, refSpecStr string, logger logr.Logger) error {
+// PushBranch force pushes the checked out branch to remoteBranch, e.g. the head branch of a pull request
+func PushBranch(ctx context.Context, path, remoteBranch string, auth transport.AuthMethod) error {
+	auth, err := resolveAuth(auth)
+	if err != nil {
+		return err
+	}
+
+	r, err := gg.PlainOpen(path)
+	if err != nil {
+		return err
+	}
+
+	head, err := r.Head()
+	if err != nil {
+		return fmt.Errorf("failed to get HEAD, path: %s, err: %s", path, err)
+	}
+
+	refSpec := config.RefSpec(fmt.Sprintf("+%s:%s", head.Name(), plumbing.NewBranchReferenceName(remoteBranch)))
+	if err := r.PushContext(ctx, &gg.PushOptions{
+		Auth:     auth,
+		RefSpecs: []config.RefSpec{refSpec},
+	}); err != nil && err != gg.NoErrAlreadyUpToDate {
+		return err
+	}
+
+	return nil
+}
+
+// Recover brings the repository at path back to a usable state. A repository that can't be opened is cloned again,
+// a dirty working tree is hard reset to the remote branch and untracked files are removed. When HEAD holds commits
+// the remote branch doesn't have, the working tree is reset to HEAD instead so that they are kept.
+func Recover(ctx context.Context, url, path, branch string, auth transport.AuthMethod, logger logr.Logger) error {
+	auth, err := resolveAuth(auth)
+	if err != nil {
+		return err
+	}
+
+	r, err := gg.PlainOpen(path)
+	if err != nil {
+		logger.Info("git repository is corrupted, cloning it again", "path", path, "reason", err.Error())
+		if err := os.RemoveAll(path); err != nil {
+			return fmt.Errorf("failed to remove repository, path: %s, err: %s", path, err)
+		}
+		return Clone(ctx, url, path, auth)
+	}
+
+	w, err := r.Worktree()
+	if err != nil {
+		return fmt.Errorf("failed to create work tree: %s, err: %s", path, err)
+	}
+
+	status, err := w.Status()
+	if err != nil {
+		return fmt.Errorf("failed to get status of work tree: %s, err: %s", path, err)
+	}
+
+	if status.IsClean() {
+		return nil
+	}
+
+	logger.Info("git working tree is dirty, resetting to remote branch", "path", path, "branch", branch, "status", status.String())
+
+	remoteBranchRefSpec := fmt.Sprintf("+refs/heads/%s:refs/remotes/origin/%s", branch, branch)
+	if err := fetchOrigin(ctx, r, remoteBranchRefSpec, auth, logger); err != nil {
+		return err
+	}
+
+	target, err := r.Reference(plumbing.NewRemoteReferenceName("origin", branch), true)
+	head, headErr := r.Head()
+	if err != nil {
+		// the branch was never pushed, fall back to the local HEAD
+		if headErr != nil {
+			return fmt.Errorf("failed to resolve reset target, branch: %s, err: %s", branch, headErr)
+		}
+		target = head
+	} else if headErr == nil && head.Hash() != target.Hash() {
+		unpushed, err := hasUnpushedCommits(r, head.Hash(), target.Hash())
+		if err != nil {
+			return fmt.Errorf("failed to compare HEAD with remote branch %s, err: %s", branch, err)
+		}
+		if unpushed {
+			// resetting to the remote would drop commits which never reached it, they are rebased by the next push
+			logger.Info("HEAD has commits missing on the remote branch, keeping them", "path", path, "branch", branch, "head", head.Hash().String())
+			target = head
+		}
+	}
+
+	if err := w.Reset(&gg.ResetOptions{Commit: target.Hash(), Mode: gg.HardReset}); err != nil {
+		return fmt.Errorf("failed to reset work tree to %s, err: %s", target.Hash(), err)
+	}
+
+	if err := w.Clean(&gg.CleanOptions{Dir: true}); err != nil {
+		return fmt.Errorf("failed to clean work tree: %s, err: %s", path, err)
+	}
+
+	logger.Info("git working tree recovered", "path", path, "commit", target.Hash().String())
+
+	return nil
+}
+
+// hasUnpushedCommits reports whether local is neither remote nor one of its ancestors
+func hasUnpushedCommits(r *gg.Repository, local, remote plumbing.Hash) (bool, error) {
+	localCommit, err := r.CommitObject(local)
+	if err != nil {
+		return false, err
+	}
+	remoteCommit, err := r.CommitObject(remote)
+	if err != nil {
+		return false, err
+	}
+
+	pushed, err := localCommit.IsAncestor(remoteCommit)
+	if err != nil {
+		return false, err
+	}
+	return !pushed, nil
+}
+
+func fetchOrigin(ctx context.Context, repo *gg.Repository, refSpecStr string, auth transport.AuthMethod, logger logr.Logger) error {
 	remote, err := repo.Remote("origin")
 	if err != nil {
 		return err
@@ -140,13 +684,16 @@ func fetchOrigin(repo *gg.Repository, refSpecStr string, logger logr.Logger) err
 		refSpecs = []config.RefSpec{config.RefSpec(refSpecStr)}
 	}
 
-	if err = remote.Fetch(&gg.FetchOptions{
+	if err = remote.FetchContext(ctx, &gg.FetchOptions{
 		RefSpecs: refSpecs,
+		Auth:     auth,
 	}); err != nil {
 		if err == gg.NoErrAlreadyUpToDate {
 			logger.Info("refs already up to date")
 		} else if _, ok := err.(gg.NoMatchingRefSpecError); ok {
 			logger.Info("refs does not exits in remote")
+		} else if err == gg.ErrForceNeeded {
+			logger.Info("local refs are ahead of remote, keeping local commits")
 		} else {
 			return fmt.Errorf("fetch origin failed: %v", err)
 		}
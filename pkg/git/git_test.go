@@ -0,0 +1,240 @@
+package git
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	gg "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-logr/logr"
+)
+
+func TestCommitPendingAfterRestart(t *testing.T) {
+	path := initRepo(t)
+	logger := logr.Discard()
+	identity := Identity{Committer: Author{Name: "tracer-bot", Email: "bot@example.com"}}
+
+	if err := CommitChange(path, "a.yaml", OperationCreate, "alice", "kubectl", "", []byte("a: 1\n"), nil, identity, logger); err != nil {
+		t.Fatal(err)
+	}
+	// the run is interrupted with a staged batch and a file written but not staged
+	if err := StageChange(path, "b.yaml", []byte("b: 1\n"), logger); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(path, "c.yaml"), []byte("c: 1\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := gg.PlainOpen(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	before, err := r.Head()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the restarted run finds the repository and flushes the pending changes before serving
+	if !IsRepository(path) {
+		t.Fatal("expected the repository of the previous run to be found")
+	}
+	committed, err := CommitPending(path, identity, logger)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !committed {
+		t.Fatal("expected the pending changes to be committed")
+	}
+
+	head, err := r.Head()
+	if err != nil {
+		t.Fatal(err)
+	}
+	commit, err := r.CommitObject(head.Hash())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(commit.ParentHashes) != 1 || commit.ParentHashes[0] != before.Hash() {
+		t.Errorf("expected the pending changes on top of the last commit, got parents %v", commit.ParentHashes)
+	}
+	for _, name := range []string{"a.yaml", "b.yaml", "c.yaml"} {
+		if _, err := commit.File(name); err != nil {
+			t.Errorf("expected %s after the restart: %s", name, err)
+		}
+	}
+	if commit.Author.Name != pendingAuthor || commit.Author.Email != pendingAuthor+"@"+DefaultAuthorEmailDomain {
+		t.Errorf("expected the tracer as author, got %s <%s>", commit.Author.Name, commit.Author.Email)
+	}
+	if commit.Committer.Name != "tracer-bot" || commit.Committer.Email != "bot@example.com" {
+		t.Errorf("expected the configured committer, got %s <%s>", commit.Committer.Name, commit.Committer.Email)
+	}
+
+	committed, err = CommitPending(path, identity, logger)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if committed {
+		t.Error("expected nothing left to commit")
+	}
+}
+
+// initRemote creates a bare repository in a temporary directory and adds it as the origin of the repository at path
+func initRemote(t *testing.T, path string) string {
+	t.Helper()
+	remote := t.TempDir()
+	if _, err := gg.PlainInit(remote, true); err != nil {
+		t.Fatal(err)
+	}
+	r, err := gg.PlainOpen(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.CreateRemote(&config.RemoteConfig{Name: "origin", URLs: []string{remote}}); err != nil {
+		t.Fatal(err)
+	}
+	return remote
+}
+
+// dirty leaves a partial write and a stray file in the working tree at path, as a crash would
+func dirty(t *testing.T, path string) {
+	t.Helper()
+	if err := os.WriteFile(filepath.Join(path, "a.yaml"), []byte("a: "), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(path, "stray.yaml"), []byte("x: 1\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRecover(t *testing.T) {
+	ctx := context.Background()
+	logger := logr.Discard()
+
+	cases := []struct {
+		name string
+		// prepare returns the path of the repository and the commit Recover must reset it to
+		prepare func(t *testing.T) (string, plumbing.Hash)
+	}{
+		{name: "unpushed commits are kept", prepare: func(t *testing.T) (string, plumbing.Hash) {
+			path := initRepo(t)
+			initRemote(t, path)
+			commit(t, path, "a.yaml", "a: 1\n")
+			if err := PushToRemote(ctx, path, nil, PushOptions{}, logger); err != nil {
+				t.Fatal(err)
+			}
+			return path, commit(t, path, "b.yaml", "b: 1\n")
+		}},
+		{name: "behind the remote", prepare: func(t *testing.T) (string, plumbing.Hash) {
+			path := initRepo(t)
+			remote := initRemote(t, path)
+			commit(t, path, "a.yaml", "a: 1\n")
+			if err := PushToRemote(ctx, path, nil, PushOptions{}, logger); err != nil {
+				t.Fatal(err)
+			}
+			other := t.TempDir()
+			if _, err := gg.PlainClone(other, false, &gg.CloneOptions{URL: remote}); err != nil {
+				t.Fatal(err)
+			}
+			pushed := commit(t, other, "b.yaml", "b: 1\n")
+			if err := PushToRemote(ctx, other, nil, PushOptions{}, logger); err != nil {
+				t.Fatal(err)
+			}
+			return path, pushed
+		}},
+		{name: "branch never pushed", prepare: func(t *testing.T) (string, plumbing.Hash) {
+			path := initRepo(t)
+			initRemote(t, path)
+			commit(t, path, "a.yaml", "a: 1\n")
+			if err := PushToRemote(ctx, path, nil, PushOptions{}, logger); err != nil {
+				t.Fatal(err)
+			}
+			r, err := gg.PlainOpen(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			w, err := r.Worktree()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := w.Checkout(&gg.CheckoutOptions{Branch: plumbing.NewBranchReferenceName("feature"), Create: true}); err != nil {
+				t.Fatal(err)
+			}
+			return path, commit(t, path, "b.yaml", "b: 1\n")
+		}},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			path, want := c.prepare(t)
+			dirty(t, path)
+
+			r, err := gg.PlainOpen(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			head, err := r.Head()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := Recover(ctx, "", path, head.Name().Short(), nil, logger); err != nil {
+				t.Fatal(err)
+			}
+
+			if head, err = r.Head(); err != nil {
+				t.Fatal(err)
+			}
+			if head.Hash() != want {
+				t.Errorf("expected HEAD at %s, got %s", want, head.Hash())
+			}
+			w, err := r.Worktree()
+			if err != nil {
+				t.Fatal(err)
+			}
+			status, err := w.Status()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !status.IsClean() {
+				t.Errorf("expected a clean working tree, got %s", status)
+			}
+
+			// startup checks out the branch and delivers the kept commits
+			if err := Checkout(ctx, path, head.Name().Short(), nil, logger); err != nil {
+				t.Fatal(err)
+			}
+			if err := PushToRemote(ctx, path, nil, PushOptions{}, logger); err != nil {
+				t.Fatal(err)
+			}
+			if head, err = r.Head(); err != nil {
+				t.Fatal(err)
+			}
+			remote, err := r.Reference(plumbing.NewRemoteReferenceName("origin", head.Name().Short()), true)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if head.Hash() != want || remote.Hash() != want {
+				t.Errorf("expected HEAD and the remote branch at %s, got %s and %s", want, head.Hash(), remote.Hash())
+			}
+		})
+	}
+}
+
+// commit commits contents to file in the repository at path and returns the commit
+func commit(t *testing.T, path, file, contents string) plumbing.Hash {
+	t.Helper()
+	if err := CommitChange(path, file, OperationCreate, "alice", "kubectl", "", []byte(contents), nil, Identity{}, logr.Discard()); err != nil {
+		t.Fatal(err)
+	}
+	r, err := gg.PlainOpen(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	head, err := r.Head()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return head.Hash()
+}
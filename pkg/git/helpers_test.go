@@ -0,0 +1,17 @@
+package git
+
+import (
+	"testing"
+
+	gg "github.com/go-git/go-git/v5"
+)
+
+// initRepo creates an empty repository in a temporary directory
+func initRepo(t *testing.T) string {
+	t.Helper()
+	path := t.TempDir()
+	if _, err := gg.PlainInit(path, false); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
@@ -0,0 +1,27 @@
+package git
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Trailer is a "Key: Value" line appended to the commit message
+type Trailer struct {
+	Key   string
+	Value string
+}
+
+func buildMessage(subject string, trailers []Trailer) string {
+	if len(trailers) == 0 {
+		return subject
+	}
+
+	var b strings.Builder
+	b.WriteString(subject)
+	b.WriteString("\n")
+	for _, t := range trailers {
+		fmt.Fprintf(&b, "\n%s: %s", t.Key, t.Value)
+	}
+
+	return b.String()
+}
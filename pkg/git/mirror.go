@@ -0,0 +1,105 @@
+package git
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	gg "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	"github.com/go-logr/logr"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/util/retry"
+)
+
+// Remote is a mirror the pushed branches are copied to, e.g. an external remote for disaster recovery
+type Remote struct {
+	Name string
+	URL  string
+}
+
+// ConfigureRemotes adds the remotes to the repository at path, the url of existing remotes is updated
+func ConfigureRemotes(path string, remotes []Remote) error {
+	r, err := gg.PlainOpen(path)
+	if err != nil {
+		return fmt.Errorf("failed to open repository, path: %s, err: %s", path, err)
+	}
+
+	for _, remote := range remotes {
+		if err := ensureRemote(r, remote); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func ensureRemote(r *gg.Repository, remote Remote) error {
+	cfg, err := r.Config()
+	if err != nil {
+		return fmt.Errorf("failed to read repository config, err: %s", err)
+	}
+
+	if existing, ok := cfg.Remotes[remote.Name]; ok && len(existing.URLs) == 1 && existing.URLs[0] == remote.URL {
+		return nil
+	}
+
+	cfg.Remotes[remote.Name] = &config.RemoteConfig{Name: remote.Name, URLs: []string{remote.URL}}
+	if err := r.SetConfig(cfg); err != nil {
+		return fmt.Errorf("failed to configure remote %s, err: %s", remote.Name, err)
+	}
+
+	return nil
+}
+
+// MirrorError holds the errors of the mirrors which failed to be pushed
+type MirrorError struct {
+	Errs []error
+}
+
+func (e *MirrorError) Error() string {
+	return errors.Join(e.Errs...).Error()
+}
+
+func (e *MirrorError) Unwrap() []error {
+	return e.Errs
+}
+
+// pushMirrors force pushes branch to every mirror, the mirrors follow the local branch rather than being merged
+// into. A failing mirror doesn't keep the others from being pushed, the errors of all mirrors are returned.
+func pushMirrors(ctx context.Context, r *gg.Repository, branch plumbing.ReferenceName, mirrors []Remote, auth transport.AuthMethod, backoff wait.Backoff, logger logr.Logger) error {
+	refSpec := config.RefSpec(fmt.Sprintf("+%s:%s", branch, branch))
+
+	var errs []error
+	for _, mirror := range mirrors {
+		if err := ensureRemote(r, mirror); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		err := retry.OnError(backoff, retriablePush, func() error {
+			err := r.PushContext(ctx, &gg.PushOptions{
+				RemoteName: mirror.Name,
+				Auth:       auth,
+				RefSpecs:   []config.RefSpec{refSpec},
+			})
+			if err == gg.NoErrAlreadyUpToDate {
+				return nil
+			}
+			return err
+		})
+		if err != nil {
+			logger.Error(err, "failed to push to mirror", "remote", mirror.Name, "branch", branch.Short())
+			errs = append(errs, fmt.Errorf("failed to push to mirror %s: %s", mirror.Name, err))
+			continue
+		}
+		logger.V(1).Info("git push to mirror successfully", "remote", mirror.Name, "branch", branch.Short())
+	}
+
+	if len(errs) > 0 {
+		return &MirrorError{Errs: errs}
+	}
+	return nil
+}
@@ -0,0 +1,150 @@
+package git
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	gg "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	"github.com/go-git/go-git/v5/utils/merkletrie"
+	"github.com/go-logr/logr"
+)
+
+// rebaseOnRemote replays the local commits of branch missing on the remote on top of the remote branch.
+// Files are snapshots of objects, so when both sides changed a file the local version wins.
+func rebaseOnRemote(ctx context.Context, r *gg.Repository, path string, branch plumbing.ReferenceName, auth transport.AuthMethod, signKey *openpgp.Entity, logger logr.Logger) error {
+	remoteName := plumbing.NewRemoteReferenceName("origin", branch.Short())
+	if err := fetchOrigin(ctx, r, fmt.Sprintf("+%s:%s", branch, remoteName), auth, logger); err != nil {
+		return err
+	}
+
+	remoteRef, err := r.Reference(remoteName, true)
+	if err != nil {
+		return fmt.Errorf("failed to resolve remote branch %s, err: %s", remoteName, err)
+	}
+	remoteHead, err := r.CommitObject(remoteRef.Hash())
+	if err != nil {
+		return err
+	}
+
+	head, err := r.Head()
+	if err != nil {
+		return fmt.Errorf("failed to get HEAD, path: %s, err: %s", path, err)
+	}
+	c, err := r.CommitObject(head.Hash())
+	if err != nil {
+		return err
+	}
+
+	// local commits missing on the remote, newest first
+	var pending []*object.Commit
+	for c.Hash != remoteHead.Hash {
+		isAncestor, err := c.IsAncestor(remoteHead)
+		if err != nil {
+			return err
+		}
+		if isAncestor {
+			break
+		}
+		pending = append(pending, c)
+
+		if c.NumParents() == 0 {
+			return fmt.Errorf("local branch %s shares no history with the remote", branch.Short())
+		}
+		if c, err = c.Parent(0); err != nil {
+			return err
+		}
+	}
+
+	w, err := r.Worktree()
+	if err != nil {
+		return fmt.Errorf("failed to create work tree: %s, err: %s", path, err)
+	}
+	if err := w.Reset(&gg.ResetOptions{Commit: remoteHead.Hash, Mode: gg.HardReset}); err != nil {
+		return fmt.Errorf("failed to reset to remote branch %s, err: %s", remoteName, err)
+	}
+
+	for i := len(pending) - 1; i >= 0; i-- {
+		if err := replay(w, path, pending[i], signKey); err != nil {
+			return fmt.Errorf("failed to replay commit %s, err: %s", pending[i].Hash, err)
+		}
+	}
+
+	logger.Info("rebased local commits on remote branch", "branch", branch.Short(), "commits", len(pending))
+
+	return nil
+}
+
+// replay applies the changes of c to the work tree and commits them with the message, author and committer of c
+func replay(w *gg.Worktree, path string, c *object.Commit, signKey *openpgp.Entity) error {
+	tree, err := c.Tree()
+	if err != nil {
+		return err
+	}
+	parentTree := &object.Tree{}
+	if c.NumParents() > 0 {
+		parent, err := c.Parent(0)
+		if err != nil {
+			return err
+		}
+		if parentTree, err = parent.Tree(); err != nil {
+			return err
+		}
+	}
+
+	changes, err := object.DiffTree(parentTree, tree)
+	if err != nil {
+		return err
+	}
+
+	for _, change := range changes {
+		action, err := change.Action()
+		if err != nil {
+			return err
+		}
+
+		if action == merkletrie.Delete {
+			if _, err := os.Stat(filepath.Join(path, change.From.Name)); os.IsNotExist(err) {
+				continue
+			}
+			if _, err := w.Remove(change.From.Name); err != nil {
+				return err
+			}
+			continue
+		}
+
+		file, err := tree.File(change.To.Name)
+		if err != nil {
+			return err
+		}
+		contents, err := file.Contents()
+		if err != nil {
+			return err
+		}
+		targetFile := filepath.Join(path, change.To.Name)
+		if err := os.MkdirAll(filepath.Dir(targetFile), os.ModePerm); err != nil {
+			return err
+		}
+		if err := os.WriteFile(targetFile, []byte(contents), 0644); err != nil {
+			return err
+		}
+		if _, err := w.Add(change.To.Name); err != nil {
+			return err
+		}
+	}
+
+	author, committer := c.Author, c.Committer
+	_, err = w.Commit(c.Message, &gg.CommitOptions{
+		AllowEmptyCommits: true,
+		Author:            &author,
+		Committer:         &committer,
+		SignKey:           signKey,
+	})
+
+	return err
+}
@@ -0,0 +1,95 @@
+package git
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	gg "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
+	"github.com/go-logr/logr"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+func TestRebaseKeepsAuthorAndCommitter(t *testing.T) {
+	ctx := context.Background()
+	logger := logr.Discard()
+	opts := PushOptions{Backoff: wait.Backoff{Steps: 2, Duration: time.Millisecond}}
+	identity := Identity{Committer: Author{Name: "tracer-bot", Email: "bot@example.com"}}
+
+	remote := t.TempDir()
+	if _, err := gg.PlainInit(remote, true); err != nil {
+		t.Fatal(err)
+	}
+	first := initRepo(t)
+	r, err := gg.PlainOpen(first)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.CreateRemote(&config.RemoteConfig{Name: "origin", URLs: []string{remote}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := CommitChange(first, "a.yaml", OperationCreate, "alice", "kubectl", "", []byte("a: 1\n"), nil, identity, logger); err != nil {
+		t.Fatal(err)
+	}
+	if err := PushToRemote(ctx, first, nil, opts, logger); err != nil {
+		t.Fatal(err)
+	}
+
+	// another replica pushes while the local commit is made
+	second := t.TempDir()
+	if _, err := gg.PlainClone(second, false, &gg.CloneOptions{URL: remote}); err != nil {
+		t.Fatal(err)
+	}
+	if err := CommitChange(second, "b.yaml", OperationCreate, "bob", "helm", "", []byte("b: 1\n"), nil, identity, logger); err != nil {
+		t.Fatal(err)
+	}
+	if err := PushToRemote(ctx, second, nil, opts, logger); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := CommitChange(first, "c.yaml", OperationCreate, "carol", "kubectl", "", []byte("c: 1\n"), nil, identity, logger); err != nil {
+		t.Fatal(err)
+	}
+	head, err := r.Head()
+	if err != nil {
+		t.Fatal(err)
+	}
+	local, err := r.CommitObject(head.Hash())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := PushToRemote(ctx, first, nil, opts, logger); err != nil {
+		t.Fatalf("expected the push to succeed after rebasing: %s", err)
+	}
+
+	remoteRepo, err := gg.PlainOpen(remote)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ref, err := remoteRepo.Reference(head.Name(), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	replayed, err := remoteRepo.CommitObject(ref.Hash())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if replayed.Hash == local.Hash {
+		t.Fatal("expected the local commit to be replayed on the remote branch")
+	}
+	if replayed.Message != local.Message {
+		t.Errorf("expected message %q, got %q", local.Message, replayed.Message)
+	}
+	if replayed.Author.Name != local.Author.Name || replayed.Author.Email != local.Author.Email {
+		t.Errorf("expected author %s <%s>, got %s <%s>", local.Author.Name, local.Author.Email, replayed.Author.Name, replayed.Author.Email)
+	}
+	if replayed.Committer.Name != "tracer-bot" || replayed.Committer.Email != "bot@example.com" {
+		t.Errorf("expected the committer to be kept, got %s <%s>", replayed.Committer.Name, replayed.Committer.Email)
+	}
+	for _, name := range []string{"a.yaml", "b.yaml", "c.yaml"} {
+		if _, err := replayed.File(name); err != nil {
+			t.Errorf("expected %s on the remote: %s", name, err)
+		}
+	}
+}
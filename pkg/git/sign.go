@@ -0,0 +1,33 @@
+package git
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+)
+
+// LoadSignKey reads the first private key of an armored gpg key ring, passphrase decrypts it when it is encrypted
+func LoadSignKey(armored, passphrase []byte) (*openpgp.Entity, error) {
+	entities, err := openpgp.ReadArmoredKeyRing(bytes.NewReader(armored))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read gpg key, err: %s", err)
+	}
+
+	for _, entity := range entities {
+		if entity.PrivateKey == nil {
+			continue
+		}
+		if entity.PrivateKey.Encrypted {
+			if len(passphrase) == 0 {
+				return nil, fmt.Errorf("gpg key %s is encrypted and no passphrase is set", entity.PrimaryKey.KeyIdString())
+			}
+			if err := entity.DecryptPrivateKeys(passphrase); err != nil {
+				return nil, fmt.Errorf("failed to decrypt gpg key %s, err: %s", entity.PrimaryKey.KeyIdString(), err)
+			}
+		}
+		return entity, nil
+	}
+
+	return nil, fmt.Errorf("no private key found in gpg key ring")
+}
@@ -0,0 +1,107 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	crmetrics "sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+var (
+	// sizes from 256B to 4MiB
+	sizeBuckets = prometheus.ExponentialBuckets(256, 4, 8)
+
+	StoredObjectBytes = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "tracer_stored_object_bytes",
+		Help:    "Size of the serialized objects written to storage",
+		Buckets: sizeBuckets,
+	}, []string{"gvk"})
+
+	DiffBytes = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "tracer_diff_bytes",
+		Help:    "Size of the rendered diffs of changed objects",
+		Buckets: sizeBuckets,
+	}, []string{"gvk"})
+
+	ConsecutiveGitFailures = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "tracer_consecutive_git_failures",
+		Help: "Number of consecutive failures to commit or push changes",
+	})
+
+	PersistentGitFailures = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "tracer_persistent_git_failures_total",
+		Help: "Number of times the consecutive git failures reached the alerting threshold",
+	})
+
+	InflightHandlers = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "tracer_inflight_handlers",
+		Help: "Number of admission requests being traced",
+	})
+
+	SaturatedHandlers = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "tracer_saturated_handlers_total",
+		Help: "Number of admission requests admitted without tracing because too many requests were in flight",
+	})
+
+	DeadLetterDepth = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "tracer_dead_letter_depth",
+		Help: "Number of changes waiting in the dead letter store to be replayed",
+	})
+
+	DriftDetected = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "tracer_drift_detected_total",
+		Help: "Number of admitted objects which differ from the version stored in git",
+	}, []string{"gvk"})
+
+	OversizedObjects = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "tracer_oversized_objects_total",
+		Help: "Number of admission requests admitted without tracing because the object exceeded the maximum size",
+	}, []string{"kind"})
+
+	ThrottledChanges = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "tracer_throttled_changes_total",
+		Help: "Number of changes logged but not committed because the object exceeded the per object rate",
+	}, []string{"gvk"})
+
+	FollowerChanges = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "tracer_follower_changes_total",
+		Help: "Number of changes received by a replica which is not the leader and not committed to git",
+	}, []string{"gvk"})
+
+	AdmissionRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "tracer_admission_requests_total",
+		Help: "Number of admission requests handled, including the ones not traced",
+	}, []string{"kind", "operation"})
+
+	ChangesDetected = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "tracer_changes_detected_total",
+		Help: "Number of admission requests which changed the traced object",
+	}, []string{"gvk", "operation", "namespace"})
+
+	GitCommits = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "tracer_git_commits_total",
+		Help: "Number of git commits by result, success or failure",
+	}, []string{"result"})
+
+	PushDuration = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Name:    "tracer_git_push_duration_seconds",
+		Help:    "Duration of pushes to the remote including retries",
+		Buckets: prometheus.DefBuckets,
+	})
+
+	DiffDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name: "tracer_diff_duration_seconds",
+		Help: "Duration of diffing the old and new object",
+		// diffs take microseconds to tens of milliseconds
+		Buckets: prometheus.ExponentialBuckets(0.00005, 4, 8),
+	}, []string{"gvk"})
+)
+
+// Result labels of GitCommits
+const (
+	ResultSuccess = "success"
+	ResultFailure = "failure"
+)
+
+func init() {
+	crmetrics.Registry.MustRegister(StoredObjectBytes, DiffBytes, ConsecutiveGitFailures, PersistentGitFailures, InflightHandlers, SaturatedHandlers, DeadLetterDepth, DriftDetected,
+		AdmissionRequests, ChangesDetected, GitCommits, PushDuration, DiffDuration, OversizedObjects, ThrottledChanges, FollowerChanges)
+}
@@ -0,0 +1,73 @@
+package review
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+)
+
+const giteaPageSize = 50
+
+type GiteaProvider struct {
+	// Repository is owner/name of the repository
+	Repository string
+	api        *apiClient
+}
+
+type giteaPullRequest struct {
+	HTMLURL string `json:"html_url"`
+	Head    struct {
+		Ref string `json:"ref"`
+	} `json:"head"`
+	Base struct {
+		Ref string `json:"ref"`
+	} `json:"base"`
+}
+
+// NewGiteaProvider creates a provider for the gitea instance at baseURL, e.g. https://gitea.example.com
+func NewGiteaProvider(baseURL, repository, token string) *GiteaProvider {
+	return &GiteaProvider{
+		Repository: repository,
+		api:        newAPIClient(baseURL+"/api/v1", map[string]string{"Authorization": "token " + token}),
+	}
+}
+
+func (g *GiteaProvider) EnsurePullRequest(ctx context.Context, pr PullRequest) (string, error) {
+	existing, err := g.findOpen(ctx, pr.Head, pr.Base)
+	if err != nil {
+		return "", fmt.Errorf("failed to list pull requests of %s: %s", g.Repository, err)
+	}
+
+	// the pull request follows the pushed head branch, nothing to update
+	if existing != nil {
+		return existing.HTMLURL, nil
+	}
+
+	created := giteaPullRequest{}
+	body := map[string]string{"title": pr.Title, "body": pr.Body, "head": pr.Head, "base": pr.Base}
+	if err := g.api.do(ctx, http.MethodPost, fmt.Sprintf("/repos/%s/pulls", g.Repository), body, &created); err != nil {
+		return "", fmt.Errorf("failed to create pull request in %s: %s", g.Repository, err)
+	}
+
+	return created.HTMLURL, nil
+}
+
+func (g *GiteaProvider) findOpen(ctx context.Context, head, base string) (*giteaPullRequest, error) {
+	for page := 1; ; page++ {
+		var prs []giteaPullRequest
+		path := fmt.Sprintf("/repos/%s/pulls?state=open&limit=%d&page=%d", g.Repository, giteaPageSize, page)
+		if err := g.api.do(ctx, http.MethodGet, path, nil, &prs); err != nil {
+			return nil, err
+		}
+
+		for i := range prs {
+			if prs[i].Head.Ref == head && prs[i].Base.Ref == base {
+				return &prs[i], nil
+			}
+		}
+
+		if len(prs) < giteaPageSize {
+			return nil, nil
+		}
+	}
+}
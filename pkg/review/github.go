@@ -0,0 +1,58 @@
+package review
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+const githubAPI = "https://api.github.com"
+
+type GitHubProvider struct {
+	// Repository is owner/name of the repository
+	Repository string
+	api        *apiClient
+}
+
+type githubPullRequest struct {
+	HTMLURL string `json:"html_url"`
+}
+
+// NewGitHubProvider creates a provider for github.com, or for the github enterprise instance at baseURL,
+// e.g. https://github.example.com
+func NewGitHubProvider(baseURL, repository, token string) *GitHubProvider {
+	api := githubAPI
+	if baseURL = strings.TrimSuffix(baseURL, "/"); baseURL != "" && baseURL != "https://github.com" {
+		api = baseURL + "/api/v3"
+	}
+
+	return &GitHubProvider{
+		Repository: repository,
+		api:        newAPIClient(api, map[string]string{"Authorization": "Bearer " + token}),
+	}
+}
+
+func (g *GitHubProvider) EnsurePullRequest(ctx context.Context, pr PullRequest) (string, error) {
+	owner, _, _ := strings.Cut(g.Repository, "/")
+
+	var existing []githubPullRequest
+	query := url.Values{"state": {"open"}, "head": {owner + ":" + pr.Head}, "base": {pr.Base}}
+	if err := g.api.do(ctx, http.MethodGet, fmt.Sprintf("/repos/%s/pulls?%s", g.Repository, query.Encode()), nil, &existing); err != nil {
+		return "", fmt.Errorf("failed to list pull requests of %s: %s", g.Repository, err)
+	}
+
+	// the pull request follows the pushed head branch, nothing to update
+	if len(existing) > 0 {
+		return existing[0].HTMLURL, nil
+	}
+
+	created := githubPullRequest{}
+	body := map[string]string{"title": pr.Title, "body": pr.Body, "head": pr.Head, "base": pr.Base}
+	if err := g.api.do(ctx, http.MethodPost, fmt.Sprintf("/repos/%s/pulls", g.Repository), body, &created); err != nil {
+		return "", fmt.Errorf("failed to create pull request in %s: %s", g.Repository, err)
+	}
+
+	return created.HTMLURL, nil
+}
@@ -0,0 +1,57 @@
+package review
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+const gitlabURL = "https://gitlab.com"
+
+type GitLabProvider struct {
+	// Repository is the path of the project, e.g. group/subgroup/name
+	Repository string
+	api        *apiClient
+}
+
+type gitlabMergeRequest struct {
+	WebURL string `json:"web_url"`
+}
+
+// NewGitLabProvider creates a provider for the gitlab instance at baseURL, gitlab.com if empty
+func NewGitLabProvider(baseURL, repository, token string) *GitLabProvider {
+	if baseURL = strings.TrimSuffix(baseURL, "/"); baseURL == "" {
+		baseURL = gitlabURL
+	}
+
+	return &GitLabProvider{
+		Repository: repository,
+		api:        newAPIClient(baseURL+"/api/v4", map[string]string{"PRIVATE-TOKEN": token}),
+	}
+}
+
+// EnsurePullRequest opens a merge request, they are gitlab's pull requests
+func (g *GitLabProvider) EnsurePullRequest(ctx context.Context, pr PullRequest) (string, error) {
+	project := fmt.Sprintf("/projects/%s/merge_requests", url.PathEscape(g.Repository))
+
+	var existing []gitlabMergeRequest
+	query := url.Values{"state": {"opened"}, "source_branch": {pr.Head}, "target_branch": {pr.Base}}
+	if err := g.api.do(ctx, http.MethodGet, project+"?"+query.Encode(), nil, &existing); err != nil {
+		return "", fmt.Errorf("failed to list merge requests of %s: %s", g.Repository, err)
+	}
+
+	// the merge request follows the pushed source branch, nothing to update
+	if len(existing) > 0 {
+		return existing[0].WebURL, nil
+	}
+
+	created := gitlabMergeRequest{}
+	body := map[string]string{"title": pr.Title, "description": pr.Body, "source_branch": pr.Head, "target_branch": pr.Base}
+	if err := g.api.do(ctx, http.MethodPost, project, body, &created); err != nil {
+		return "", fmt.Errorf("failed to create merge request in %s: %s", g.Repository, err)
+	}
+
+	return created.WebURL, nil
+}
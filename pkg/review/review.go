@@ -0,0 +1,110 @@
+package review
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const requestTimeout = 30 * time.Second
+
+type PullRequest struct {
+	Title string
+	Body  string
+	// Head is the branch holding the changes
+	Head string
+	// Base is the branch the changes are merged into
+	Base string
+}
+
+// Provider opens pull requests on the git hosting service
+type Provider interface {
+	// EnsurePullRequest opens a pull request from head into base unless one is open already, it returns the url of the pull request
+	EnsurePullRequest(ctx context.Context, pr PullRequest) (string, error)
+}
+
+// ParseRepository extracts owner/name from a git url like https://host/owner/name.git or git@host:owner/name.git
+func ParseRepository(gitURL string) (string, error) {
+	path := ""
+	if _, scpPath, ok := strings.Cut(gitURL, ":"); ok && !strings.Contains(gitURL, "://") {
+		path = scpPath
+	} else {
+		u, err := url.Parse(gitURL)
+		if err != nil {
+			return "", fmt.Errorf("failed to parse git url %s: %s", gitURL, err)
+		}
+		path = u.Path
+	}
+
+	repo := strings.TrimSuffix(strings.Trim(path, "/"), ".git")
+	if !strings.Contains(repo, "/") {
+		return "", fmt.Errorf("failed to get repository from git url %s", gitURL)
+	}
+
+	return repo, nil
+}
+
+type apiClient struct {
+	baseURL string
+	headers map[string]string
+	client  *http.Client
+}
+
+func newAPIClient(baseURL string, headers map[string]string) *apiClient {
+	return &apiClient{
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		headers: headers,
+		client:  &http.Client{Timeout: requestTimeout},
+	}
+}
+
+func (c *apiClient) do(ctx context.Context, method, path string, body, out interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, reader)
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	for k, v := range c.headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected status %d from %s %s: %s", resp.StatusCode, method, path, strings.TrimSpace(string(data)))
+	}
+
+	if out == nil {
+		return nil
+	}
+
+	return json.Unmarshal(data, out)
+}
@@ -0,0 +1,330 @@
+package tracer
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	"github.com/go-git/go-git/v5/plumbing/transport/http"
+	"github.com/go-git/go-git/v5/plumbing/transport/ssh"
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	metricsserver "sigs.k8s.io/controller-runtime/pkg/metrics/server"
+
+	"github.com/reborn1867/k8s-resource-tracer/pkg/common"
+	"github.com/reborn1867/k8s-resource-tracer/pkg/git"
+	"github.com/reborn1867/k8s-resource-tracer/pkg/review"
+	"github.com/reborn1867/k8s-resource-tracer/pkg/webhooks/listener"
+)
+
+// prepareRepo clones and checks out the git repository, changes left by a previous run are delivered first
+func prepareRepo(ctx context.Context, lw *listener.ListenerWebhook, logger logr.Logger) error {
+	exists := git.IsRepository(lw.GitPath)
+	if exists && lw.DryRun {
+		// dry runs never commit, the changes left by a previous run are kept as they are for a later run instead of
+		// being discarded by the recovery or the checkout below
+		logger.Info("dry run on a repository left over from a previous run, using it as it is", "path", lw.GitPath)
+		return nil
+	}
+	if exists {
+		// the repository is left over from a previous run of the container, deliver its changes in order before
+		// serving new ones and before the checkout below discards uncommitted files
+		if err := lw.FlushPending(ctx); err != nil {
+			if !lw.AutoRecoverRepo {
+				return fmt.Errorf("failed to flush pending changes, path: %s, err: %s", lw.GitPath, err)
+			}
+			// commits which didn't reach the remote survive the recovery and are pushed with the next change
+			logger.Error(err, "failed to flush pending changes, recovering the repository", "path", lw.GitPath)
+		}
+	}
+
+	if lw.AutoRecoverRepo {
+		// the repository may be left over from a previous run of the container
+		if err := git.Recover(ctx, lw.GitURL, lw.GitPath, lw.GitBranch, lw.GitAuth, logger); err != nil {
+			return fmt.Errorf("failed to recover git repo, url: %s, path: %s, err: %s", lw.GitURL, lw.GitPath, err)
+		}
+	} else if !exists {
+		if err := git.Clone(ctx, lw.GitURL, lw.GitPath, lw.GitAuth); err != nil {
+			return fmt.Errorf("failed to clone git repo, url: %s, path: %s, err: %s", lw.GitURL, lw.GitPath, err)
+		}
+	}
+
+	if err := git.Checkout(ctx, lw.GitPath, lw.GitBranch, lw.GitAuth, logger); err != nil {
+		return fmt.Errorf("failed to checkout to git branch, path: %s, branch: %s, err: %s", lw.GitPath, lw.GitBranch, err)
+	}
+
+	if err := git.ConfigureRemotes(lw.GitPath, lw.PushOptions.Mirrors); err != nil {
+		return fmt.Errorf("failed to configure mirrors, path: %s, err: %s", lw.GitPath, err)
+	}
+
+	return nil
+}
+
+// startLeaderElection runs a lease based election in the background until ctx is done, the elected replica prepares
+// the git repository and starts writing to it. The election stopping, e.g. by losing the lease, is sent to the
+// returned channel so that the server stops and git is never written by two replicas at once.
+func startLeaderElection(ctx context.Context, lw *listener.ListenerWebhook, syncKinds []schema.GroupVersionKind, id, namespace string, leaseDuration, renewDeadline, retryPeriod time.Duration, logger logr.Logger) (<-chan error, error) {
+	cfg, err := ctrl.GetConfig()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get kubeconfig: %s", err)
+	}
+
+	mgr, err := ctrl.NewManager(cfg, ctrl.Options{
+		Logger:                  logger,
+		LeaderElection:          true,
+		LeaderElectionID:        id,
+		LeaderElectionNamespace: namespace,
+		LeaseDuration:           &leaseDuration,
+		RenewDeadline:           &renewDeadline,
+		RetryPeriod:             &retryPeriod,
+		Metrics:                 metricsserver.Options{BindAddress: "0"},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create manager: %s", err)
+	}
+
+	if err := mgr.Add(manager.RunnableFunc(func(ctx context.Context) error {
+		logger.Info("elected as leader", "lease", id)
+		if lw.EnableGitReview {
+			if err := prepareRepo(ctx, lw, logger); err != nil {
+				return err
+			}
+		}
+		lw.StartLeading()
+		if len(syncKinds) > 0 {
+			if err := runInitialSync(ctx, lw, syncKinds, logger); err != nil {
+				return err
+			}
+		}
+
+		<-ctx.Done()
+		return nil
+	})); err != nil {
+		return nil, err
+	}
+
+	stopped := make(chan error, 1)
+	go func() {
+		err := mgr.Start(ctx)
+		if err == nil && ctx.Err() == nil {
+			err = fmt.Errorf("manager stopped")
+		}
+		if err != nil {
+			stopped <- err
+		}
+	}()
+
+	return stopped, nil
+}
+
+// staticAuth builds the git auth from the environment, the same auth is used for clone, fetch and push
+func staticAuth(method string) (transport.AuthMethod, error) {
+	userName, _ := os.LookupEnv("GIT_USER_NAME")
+
+	switch method {
+	case "basic":
+		pwd, _ := os.LookupEnv("GIT_PASSWORD")
+		return &http.BasicAuth{
+			Username: userName,
+			Password: pwd,
+		}, nil
+	case "ssh":
+		keyPath, ok := os.LookupEnv("GIT_SSH_KEY_PATH")
+		if !ok {
+			return nil, fmt.Errorf("env GIT_SSH_KEY_PATH is required for ssh auth")
+		}
+		if userName == "" {
+			userName = ssh.DefaultUsername
+		}
+		passphrase, _ := os.LookupEnv("GIT_SSH_KEY_PASSPHRASE")
+		return ssh.NewPublicKeysFromFile(userName, keyPath, passphrase)
+	case "token":
+		token, ok := os.LookupEnv("GIT_TOKEN")
+		if !ok {
+			return nil, fmt.Errorf("env GIT_TOKEN is required for token auth")
+		}
+		// git hosts expect access tokens as the password of basic auth, the user name is mostly ignored
+		if userName == "" {
+			userName = "x-access-token"
+		}
+		return &http.BasicAuth{
+			Username: userName,
+			Password: token,
+		}, nil
+	default:
+		return nil, fmt.Errorf("unknown git auth method %s", method)
+	}
+}
+
+// resolveBranch looks up the branch of the cluster, by name first and by service host second, in the mapping file
+// or configmap, an empty branch is returned when no entry matches
+func resolveBranch(c common.Client, file, configMap string, ids ...string) (string, error) {
+	mapping := git.BranchMapping{}
+	if file != "" {
+		m, err := git.LoadBranchMapping(file)
+		if err != nil {
+			return "", err
+		}
+		mapping = m
+	} else {
+		namespace, name, _ := strings.Cut(configMap, "/")
+		if err := c.GetConfigMapFieldYamlUnmarshal(context.TODO(), namespace, name, BranchMappingKey, &mapping); err != nil {
+			return "", fmt.Errorf("failed to load branch mapping, configmap: %s, err: %s", configMap, err)
+		}
+	}
+
+	branch, _ := mapping.Resolve(ids...)
+	return branch, nil
+}
+
+// loadSignKey loads the gpg key signing commits from file or else secret, nil if neither is set
+func loadSignKey(c common.Client, file, passphraseFile, secret string) (*openpgp.Entity, error) {
+	var armored, passphrase []byte
+	switch {
+	case file != "":
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read gpg key, path: %s, err: %s", file, err)
+		}
+		armored = data
+		if passphraseFile != "" {
+			data, err := os.ReadFile(passphraseFile)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read gpg key passphrase, path: %s, err: %s", passphraseFile, err)
+			}
+			passphrase = bytes.TrimSpace(data)
+		}
+	case secret != "":
+		namespace, name, _ := strings.Cut(secret, "/")
+		s := &corev1.Secret{}
+		if err := c.Get(context.TODO(), types.NamespacedName{Namespace: namespace, Name: name}, s); err != nil {
+			return nil, fmt.Errorf("failed to get gpg key, secret: %s, err: %s", secret, err)
+		}
+		if len(s.Data[SignKeySecretKey]) == 0 {
+			return nil, fmt.Errorf("empty field %s in secret %s", SignKeySecretKey, secret)
+		}
+		armored, passphrase = s.Data[SignKeySecretKey], bytes.TrimSpace(s.Data[SignPassphraseSecretKey])
+	default:
+		return nil, nil
+	}
+
+	return git.LoadSignKey(armored, passphrase)
+}
+
+// parseGVK parses group/version/Kind, the group is left out for the core group
+func parseGVK(s string) (schema.GroupVersionKind, error) {
+	parts := strings.Split(s, "/")
+	switch {
+	case len(parts) == 2 && parts[0] != "" && parts[1] != "":
+		return schema.GroupVersionKind{Version: parts[0], Kind: parts[1]}, nil
+	case len(parts) == 3 && parts[1] != "" && parts[2] != "":
+		return schema.GroupVersionKind{Group: parts[0], Version: parts[1], Kind: parts[2]}, nil
+	}
+
+	return schema.GroupVersionKind{}, fmt.Errorf("invalid kind %s, expected group/version/Kind", s)
+}
+
+func startInformers(ctx context.Context, lw *listener.ListenerWebhook, kinds []string) error {
+	var gvks []schema.GroupVersionKind
+	for _, k := range kinds {
+		gvk, _ := schema.ParseKindArg(k)
+		if gvk == nil {
+			return fmt.Errorf("invalid kind %s, expected Kind.version.group", k)
+		}
+		gvks = append(gvks, *gvk)
+	}
+
+	cfg, err := ctrl.GetConfig()
+	if err != nil {
+		return fmt.Errorf("failed to get kubeconfig: %s", err)
+	}
+
+	if lw.Client == nil {
+		c, err := newClient()
+		if err != nil {
+			return err
+		}
+		lw.Client = c
+	}
+
+	return lw.StartInformers(ctx, cfg, lw.Client.RESTMapper(), gvks)
+}
+
+func newReviewProvider(provider, apiURL, gitURL string) (review.Provider, error) {
+	repo, err := review.ParseRepository(gitURL)
+	if err != nil {
+		return nil, err
+	}
+
+	token, _ := os.LookupEnv("REVIEW_API_TOKEN")
+
+	switch provider {
+	case "gitea":
+		return review.NewGiteaProvider(apiURL, repo, token), nil
+	case "github":
+		return review.NewGitHubProvider(apiURL, repo, token), nil
+	case "gitlab":
+		return review.NewGitLabProvider(apiURL, repo, token), nil
+	default:
+		return nil, fmt.Errorf("unknown review provider %s", provider)
+	}
+}
+
+// initialSyncKinds are the kinds traced by traceGVK and informerKind, the webhook rules can't be listed
+func initialSyncKinds(lw *listener.ListenerWebhook, informerKinds []string) ([]schema.GroupVersionKind, error) {
+	if !lw.EnableGitReview {
+		return nil, fmt.Errorf("initialSync requires enableGitReview")
+	}
+
+	gvks := append([]schema.GroupVersionKind{}, lw.ResourceSelectors...)
+	for _, k := range informerKinds {
+		gvk, _ := schema.ParseKindArg(k)
+		if gvk == nil {
+			return nil, fmt.Errorf("invalid kind %s, expected Kind.version.group", k)
+		}
+		gvks = append(gvks, *gvk)
+	}
+
+	if len(gvks) == 0 {
+		return nil, fmt.Errorf("initialSync needs the kinds to list, set traceGVK or informerKind")
+	}
+	return gvks, nil
+}
+
+// runInitialSync stores the existing objects of gvks which are not stored yet
+func runInitialSync(ctx context.Context, lw *listener.ListenerWebhook, gvks []schema.GroupVersionKind, logger logr.Logger) error {
+	if lw.Client == nil {
+		c, err := newClient()
+		if err != nil {
+			return err
+		}
+		lw.Client = c
+	}
+
+	logger.Info("starting initial sync", "kinds", len(gvks))
+	return lw.InitialSync(ctx, gvks)
+}
+
+func newClient() (common.Client, error) {
+	cfg, err := ctrl.GetConfig()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get kubeconfig: %s", err)
+	}
+
+	c, err := client.New(cfg, client.Options{})
+	if err != nil {
+		return nil, err
+	}
+
+	return common.NewClient(c), nil
+}
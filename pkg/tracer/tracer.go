@@ -0,0 +1,706 @@
+// Package tracer wires the listener webhook, its storage and its background jobs into a server, so that the tracer
+// can be embedded next to other webhooks and managers
+package tracer
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	"github.com/go-logr/logr"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/healthz"
+	crmetrics "sigs.k8s.io/controller-runtime/pkg/metrics"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	"github.com/reborn1867/k8s-resource-tracer/pkg/backend"
+	"github.com/reborn1867/k8s-resource-tracer/pkg/deadletter"
+	"github.com/reborn1867/k8s-resource-tracer/pkg/git"
+	"github.com/reborn1867/k8s-resource-tracer/pkg/vault"
+	"github.com/reborn1867/k8s-resource-tracer/pkg/webhooks/listener"
+)
+
+const (
+	// AuthorMappingKey is the configmap key holding the author mapping
+	AuthorMappingKey = "authors.yaml"
+	// BranchMappingKey is the configmap key holding the branch mapping
+	BranchMappingKey = "branches.yaml"
+	// SignKeySecretKey and SignPassphraseSecretKey are the secret keys holding the armored gpg key and its
+	// optional passphrase
+	SignKeySecretKey        = "signing.key"
+	SignPassphraseSecretKey = "passphrase"
+)
+
+// Config captures the flags of the tracer, see cmd/main.go for their meaning and defaults
+type Config struct {
+	Logger  logr.Logger
+	Version string
+
+	Host string
+	Port int
+
+	EnableGitReview        bool
+	DryRun                 bool
+	IgnoreStatusChanges    bool
+	CaptureFinalState      bool
+	RedactSecrets          bool
+	RedactPaths            []string
+	PartialRedactPaths     []string
+	SetKeys                []string
+	DiffOptions            []string
+	IncludeNamespaces      []string
+	ExcludeNamespaces      []string
+	TraceGVKs              []string
+	ObjectSelector         string
+	AllowPaths             []string
+	MaxManagedFields       int
+	LongStringThreshold    int
+	OutputFormat           string
+	FailurePolicy          string
+	CommitFormat           string
+	MaxObjectBytes         int
+	StoredMetadataFields   []string
+	IgnoreFields           []string
+	MergeMetadataDiff      bool
+	MetadataDiffFields     []string
+	StampProvenance        bool
+	RecordChangeAuthor     bool
+	ChangeAnnotationPrefix string
+	StoreBinaryData        bool
+	HeaderTemplate         string
+	CommitMessageTemplate  string
+	RecordTouches          bool
+	TouchGVKs              []string
+	KeepHistory            bool
+	HistoryLimit           int
+	FailureConfig          listener.FailureConfig
+	MaxConcurrentHandlers  int
+	HandlerWait            time.Duration
+
+	Backends             []string
+	DBPath               string
+	KafkaConfig          backend.KafkaConfig
+	KafkaBrokers         string
+	HTTPConfig           backend.HTTPConfig
+	S3Config             backend.S3Config
+	ObservedIndexPath    string
+	Dedup                bool
+	DedupCacheSize       int
+	DedupTTL             time.Duration
+	PerObjectRate        string
+	PerObjectBurst       int
+	DeadLetterDir        string
+	DeadLetterMaxRecords int
+
+	InformerKinds []string
+	InitialSync   bool
+	DetectDrift   bool
+	DriftPaths    []string
+
+	GitURL                 string
+	GitPath                string
+	SubPath                string
+	Branch                 string
+	BranchStrategy         string
+	ClusterName            string
+	GroupByCluster         bool
+	BranchMappingFile      string
+	BranchMappingConfigMap string
+	GroupByApp             bool
+	AppLabel               string
+	AutoRecoverRepo        bool
+	AutoGC                 bool
+	GCInterval             time.Duration
+	GitCheckInterval       time.Duration
+	CommitInterval         time.Duration
+	AuthorMappingFile      string
+	AuthorMappingConfigMap string
+	SignCommits            bool
+	SignKeyFile            string
+	SignKeyPassphraseFile  string
+	SignKeySecret          string
+	Identity               git.Identity
+	CredentialProvider     string
+	GitAuthMethod          string
+	GitCredentialsSecret   string
+	PushOptions            git.PushOptions
+	GitMirrors             []string
+	GitOpTimeout           time.Duration
+	VaultConfig            vault.Config
+
+	ReviewProvider     string
+	ReviewAPIURL       string
+	ReviewBranch       string
+	ReviewByAnnotation bool
+
+	EnableLeaderElection    bool
+	LeaderElectionID        string
+	LeaderElectionNamespace string
+	LeaseDuration           time.Duration
+	RenewDeadline           time.Duration
+	RetryPeriod             time.Duration
+}
+
+// Server is a configured listener webhook with its background jobs running
+type Server struct {
+	Listener *listener.ListenerWebhook
+
+	cfg       Config
+	readiness healthz.Checker
+	flushers  sync.WaitGroup
+	// closers release the backends once the background jobs are done
+	closers []func() error
+	// leaderLost is sent the error of the leader election, stopLeader releases the lease after Shutdown
+	leaderLost <-chan error
+	stopLeader context.CancelFunc
+}
+
+// Run serves the tracer on cfg.Host and cfg.Port until ctx is done and delivers the pending changes afterwards
+func Run(ctx context.Context, cfg Config) error {
+	s, err := NewServer(ctx, cfg)
+	if err != nil {
+		return err
+	}
+
+	return s.Start(ctx)
+}
+
+// NewServer validates cfg, prepares the git repository unless a leader is elected first and starts the background
+// jobs, which run until ctx is done
+func NewServer(ctx context.Context, cfg Config) (*Server, error) {
+	logger := cfg.Logger
+	k8sHost := os.Getenv("KUBERNETES_SERVICE_HOST")
+
+	lw := &listener.ListenerWebhook{
+		Logger:                 logger,
+		EnableGitReview:        cfg.EnableGitReview,
+		DryRun:                 cfg.DryRun,
+		IgnoreStatusChanges:    cfg.IgnoreStatusChanges,
+		CaptureFinalState:      cfg.CaptureFinalState,
+		RedactSecrets:          cfg.RedactSecrets,
+		IncludeNamespaces:      cfg.IncludeNamespaces,
+		ExcludeNamespaces:      cfg.ExcludeNamespaces,
+		MaxManagedFields:       cfg.MaxManagedFields,
+		LongStringThreshold:    cfg.LongStringThreshold,
+		OutputFormat:           cfg.OutputFormat,
+		FailurePolicy:          cfg.FailurePolicy,
+		CommitFormat:           cfg.CommitFormat,
+		MaxObjectBytes:         cfg.MaxObjectBytes,
+		StampProvenance:        cfg.StampProvenance,
+		RecordChangeAuthor:     cfg.RecordChangeAuthor,
+		ChangeAnnotationPrefix: cfg.ChangeAnnotationPrefix,
+		StoreBinaryData:        cfg.StoreBinaryData,
+		MaxConcurrentHandlers:  cfg.MaxConcurrentHandlers,
+		MergeMetadataDiff:      cfg.MergeMetadataDiff,
+		MetadataDiffFields:     cfg.MetadataDiffFields,
+		HandlerWait:            cfg.HandlerWait,
+		RecordTouches:          cfg.RecordTouches,
+		TouchGVKs:              cfg.TouchGVKs,
+		KeepHistory:            cfg.KeepHistory,
+		HistoryLimit:           cfg.HistoryLimit,
+		Version:                cfg.Version,
+	}
+	s := &Server{Listener: lw, cfg: cfg, readiness: healthz.Ping}
+
+	if err := listener.ValidateOutputFormat(cfg.OutputFormat); err != nil {
+		return nil, fmt.Errorf("invalid flag outputFormat, err: %s", err)
+	}
+	if err := listener.ValidateFailurePolicy(cfg.FailurePolicy); err != nil {
+		return nil, fmt.Errorf("invalid flag failurePolicy, err: %s", err)
+	}
+	if err := listener.ValidateCommitFormat(cfg.CommitFormat); err != nil {
+		return nil, fmt.Errorf("invalid flag commitFormat, err: %s", err)
+	}
+
+	for _, g := range cfg.TraceGVKs {
+		gvk, err := parseGVK(g)
+		if err != nil {
+			return nil, fmt.Errorf("invalid flag traceGVK, err: %s", err)
+		}
+		lw.ResourceSelectors = append(lw.ResourceSelectors, gvk)
+	}
+
+	if cfg.ObjectSelector != "" {
+		selector, err := labels.Parse(cfg.ObjectSelector)
+		if err != nil {
+			return nil, fmt.Errorf("invalid flag objectSelector, err: %s", err)
+		}
+		lw.ObjectSelector = selector
+	}
+
+	header, err := listener.ParseHeaderTemplate(cfg.HeaderTemplate)
+	if err != nil {
+		return nil, fmt.Errorf("invalid flag headerTemplate, err: %s", err)
+	}
+	lw.HeaderTemplate = header
+
+	message, err := listener.ParseCommitMessageTemplate(cfg.CommitMessageTemplate)
+	if err != nil {
+		return nil, fmt.Errorf("invalid flag commitMessageTemplate, err: %s", err)
+	}
+	lw.CommitMessageTemplate = message
+
+	lw.IgnoreFields = append(append([]string{}, listener.DefaultIgnoredFields...), cfg.IgnoreFields...)
+	lw.StoredMetadataFields = listener.DefaultStoredMetadataFields
+	if len(cfg.StoredMetadataFields) > 0 {
+		lw.StoredMetadataFields = cfg.StoredMetadataFields
+	}
+
+	if cfg.DeadLetterDir != "" {
+		store, err := deadletter.NewStore(cfg.DeadLetterDir, cfg.DeadLetterMaxRecords)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open dead letter store, path: %s, err: %s", cfg.DeadLetterDir, err)
+		}
+		lw.DeadLetters = store
+	}
+
+	if cfg.Dedup {
+		if cfg.DedupCacheSize <= 0 {
+			return nil, fmt.Errorf("invalid flag dedupCacheSize, must be positive, got %d", cfg.DedupCacheSize)
+		}
+		if cfg.DedupTTL <= 0 {
+			return nil, fmt.Errorf("invalid flag dedupTTL, must be positive, got %s", cfg.DedupTTL)
+		}
+		lw.Dedup = listener.NewDedup(cfg.DedupCacheSize, cfg.DedupTTL)
+	}
+
+	if cfg.PerObjectRate != "" {
+		limit, err := listener.ParseRate(cfg.PerObjectRate)
+		if err != nil {
+			return nil, fmt.Errorf("invalid flag perObjectRate, err: %s", err)
+		}
+		if cfg.PerObjectBurst <= 0 {
+			return nil, fmt.Errorf("invalid flag perObjectBurst, must be positive, got %d", cfg.PerObjectBurst)
+		}
+		lw.RateLimiter = listener.NewRateLimiter(limit, cfg.PerObjectBurst, listener.DefaultRateLimitCacheSize)
+	}
+
+	if cfg.ObservedIndexPath != "" {
+		idx, err := listener.NewObservedIndex(cfg.ObservedIndexPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load observed index, path: %s, err: %s", cfg.ObservedIndexPath, err)
+		}
+		s.flushers.Add(1)
+		go func() {
+			defer s.flushers.Done()
+			idx.Run(ctx, 10*time.Second, logger)
+		}()
+		lw.ObservedIndex = idx
+	}
+
+	if err := s.addBackends(ctx); err != nil {
+		return nil, err
+	}
+
+	for _, raw := range cfg.AllowPaths {
+		p, err := listener.ParsePath(raw)
+		if err != nil {
+			return nil, fmt.Errorf("invalid allow path, path: %s, err: %s", raw, err)
+		}
+		lw.AllowPaths = append(lw.AllowPaths, p)
+	}
+
+	lw.DetectDrift = cfg.DetectDrift
+	for _, raw := range cfg.DriftPaths {
+		p, err := listener.ParsePath(raw)
+		if err != nil {
+			logger.Error(err, "ignoring invalid drift path", "path", raw)
+			continue
+		}
+		lw.DriftPaths = append(lw.DriftPaths, p)
+	}
+
+	for _, raw := range cfg.RedactPaths {
+		p, err := listener.ParsePath(raw)
+		if err != nil {
+			logger.Error(err, "ignoring invalid redaction path", "path", raw)
+			continue
+		}
+		lw.RedactPaths = append(lw.RedactPaths, p)
+	}
+
+	for _, raw := range cfg.SetKeys {
+		p, err := listener.ParseSetPath(raw)
+		if err != nil {
+			return nil, fmt.Errorf("invalid set path, path: %s, err: %s", raw, err)
+		}
+		lw.SetKeys = append(lw.SetKeys, p)
+	}
+
+	for _, raw := range cfg.DiffOptions {
+		o, err := listener.ParseDiffOption(raw)
+		if err != nil {
+			return nil, fmt.Errorf("invalid flag diffOption, err: %s", err)
+		}
+		lw.DiffOptions = append(lw.DiffOptions, o)
+	}
+
+	for _, raw := range cfg.PartialRedactPaths {
+		p, err := listener.ParsePath(raw)
+		if err != nil {
+			logger.Error(err, "ignoring invalid partial redaction path", "path", raw)
+			continue
+		}
+		lw.PartialRedactPaths = append(lw.PartialRedactPaths, p)
+	}
+
+	if cfg.CaptureFinalState {
+		c, err := newClient()
+		if err != nil {
+			return nil, fmt.Errorf("failed to create kubernetes client, err: %s", err)
+		}
+		lw.Client = c
+
+		go lw.RunFinalizerRelease(ctx)
+		if len(lw.ResourceSelectors) > 0 {
+			if err := lw.QueueTerminating(ctx, lw.ResourceSelectors); err != nil {
+				return nil, fmt.Errorf("failed to queue terminating objects, err: %s", err)
+			}
+		}
+	}
+
+	if cfg.EnableGitReview {
+		if err := s.configureGit(ctx, k8sHost); err != nil {
+			return nil, err
+		}
+	}
+
+	var syncKinds []schema.GroupVersionKind
+	if cfg.InitialSync {
+		kinds, err := initialSyncKinds(lw, cfg.InformerKinds)
+		if err != nil {
+			return nil, fmt.Errorf("invalid initialSync, err: %s", err)
+		}
+		syncKinds = kinds
+	}
+	if len(syncKinds) > 0 && !cfg.EnableLeaderElection {
+		if err := runInitialSync(ctx, lw, syncKinds, logger); err != nil {
+			return nil, fmt.Errorf("failed to run initial sync, err: %s", err)
+		}
+	}
+
+	if cfg.EnableLeaderElection {
+		lw.LeaderElection = true
+		// the lease is kept until Shutdown so that the changes pushed on shutdown are still written by the leader
+		leaderCtx, stopLeader := context.WithCancel(context.Background())
+		lost, err := startLeaderElection(leaderCtx, lw, syncKinds, cfg.LeaderElectionID, cfg.LeaderElectionNamespace, cfg.LeaseDuration, cfg.RenewDeadline, cfg.RetryPeriod, logger)
+		if err != nil {
+			stopLeader()
+			return nil, fmt.Errorf("failed to start leader election, err: %s", err)
+		}
+		s.leaderLost, s.stopLeader = lost, stopLeader
+	}
+
+	if cfg.EnableGitReview && cfg.GitCheckInterval > 0 {
+		go lw.RunRemoteCheck(ctx, cfg.GitCheckInterval)
+		s.readiness = lw.CheckRemote
+	}
+
+	if cfg.EnableGitReview && cfg.AutoGC {
+		go lw.RunGC(ctx, cfg.GCInterval)
+	}
+
+	if cfg.EnableGitReview && cfg.CommitInterval > 0 {
+		s.run(ctx, func(ctx context.Context) {
+			lw.RunCommitter(ctx, cfg.CommitInterval)
+		})
+	}
+
+	if len(cfg.InformerKinds) > 0 {
+		if err := startInformers(ctx, lw, cfg.InformerKinds); err != nil {
+			return nil, fmt.Errorf("failed to start informers, err: %s", err)
+		}
+	}
+
+	return s, nil
+}
+
+// Register adds /listen, /healthz, /readyz, /metrics and with git /report to server
+func (s *Server) Register(server webhook.Server) {
+	server.Register("/listen", &admission.Webhook{Handler: s.Listener, LogConstructor: func(base logr.Logger, req *admission.Request) logr.Logger {
+		return s.cfg.Logger
+	}})
+
+	server.Register("/healthz", &healthz.CheckHandler{Checker: healthz.Ping})
+	server.Register("/readyz", &healthz.CheckHandler{Checker: func(req *http.Request) error {
+		if err := s.Listener.CheckLeader(req); err != nil {
+			return err
+		}
+		return s.readiness(req)
+	}})
+	if s.cfg.EnableGitReview {
+		server.Register("/report", s.Listener.BaselineReport())
+	}
+	server.Register("/metrics", promhttp.HandlerFor(crmetrics.Registry, promhttp.HandlerOpts{}))
+}
+
+// Start serves the handlers of Register on cfg.Host and cfg.Port until ctx is done, then calls Shutdown
+func (s *Server) Start(ctx context.Context) error {
+	server := webhook.NewServer(webhook.Options{Host: s.cfg.Host, Port: s.cfg.Port})
+	s.Register(server)
+
+	s.cfg.Logger.Info("starting k8s resource tracer", "host", s.cfg.Host, "port", s.cfg.Port, "version", s.cfg.Version)
+	serveCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	served := make(chan error, 1)
+	go func() {
+		served <- server.Start(serveCtx)
+	}()
+
+	select {
+	case err := <-served:
+		if err != nil {
+			s.releaseLease()
+			return fmt.Errorf("failed to start k8s resource tracer, err: %s", err)
+		}
+	case err := <-s.leaderLost:
+		// another replica may be writing already, nothing is pushed anymore
+		cancel()
+		<-served
+		return fmt.Errorf("leader election stopped, err: %s", err)
+	}
+
+	// the server has stopped and waited for the requests in flight, deliver what they left behind
+	s.cfg.Logger.Info("shutting down k8s resource tracer")
+	return s.Shutdown(context.Background())
+}
+
+// Shutdown waits for the background jobs, which stop once the context of NewServer is done, and pushes the pending
+// changes
+func (s *Server) Shutdown(ctx context.Context) error {
+	s.flushers.Wait()
+	defer s.releaseLease()
+	defer func() {
+		for _, c := range s.closers {
+			if err := c(); err != nil {
+				s.cfg.Logger.Error(err, "failed to close backend")
+			}
+		}
+	}()
+
+	if err := s.Listener.Shutdown(ctx); err != nil {
+		return fmt.Errorf("failed to push pending changes on shutdown, path: %s, err: %s", s.Listener.GitPath, err)
+	}
+
+	return nil
+}
+
+// run starts job in the background, Shutdown waits for it to return once ctx is done
+func (s *Server) run(ctx context.Context, job func(ctx context.Context)) {
+	s.flushers.Add(1)
+	go func() {
+		defer s.flushers.Done()
+		job(ctx)
+	}()
+}
+
+// releaseLease stops the leader election, if any
+func (s *Server) releaseLease() {
+	if s.stopLeader != nil {
+		s.stopLeader()
+	}
+}
+
+// addBackends creates the backends of cfg, the buffering ones deliver in the background until ctx is done and are
+// waited for by Shutdown
+func (s *Server) addBackends(ctx context.Context) error {
+	cfg, lw := s.cfg, s.Listener
+
+	for _, name := range cfg.Backends {
+		switch name {
+		case "sqlite":
+			b, err := backend.NewSQLiteBackend(cfg.DBPath)
+			if err != nil {
+				return fmt.Errorf("failed to create sqlite backend, path: %s, err: %s", cfg.DBPath, err)
+			}
+			lw.Backends = append(lw.Backends, b)
+			s.closers = append(s.closers, b.Close)
+		case "kafka":
+			kafkaConfig := cfg.KafkaConfig
+			if cfg.KafkaBrokers != "" {
+				kafkaConfig.Brokers = strings.Split(cfg.KafkaBrokers, ",")
+			}
+			kafkaConfig.Username, _ = os.LookupEnv("KAFKA_USERNAME")
+			kafkaConfig.Password, _ = os.LookupEnv("KAFKA_PASSWORD")
+			b, err := backend.NewKafkaBackend(kafkaConfig, cfg.Logger)
+			if err != nil {
+				return fmt.Errorf("failed to create kafka backend, brokers: %s, topic: %s, err: %s", cfg.KafkaBrokers, kafkaConfig.Topic, err)
+			}
+			s.run(ctx, b.Run)
+			lw.Backends = append(lw.Backends, b)
+		case "http":
+			httpConfig := cfg.HTTPConfig
+			httpConfig.Token, _ = os.LookupEnv("HTTP_BACKEND_TOKEN")
+			b, err := backend.NewHTTPBackend(httpConfig)
+			if err != nil {
+				return fmt.Errorf("failed to create http backend, url: %s, err: %s", httpConfig.URL, err)
+			}
+			lw.Backends = append(lw.Backends, b)
+		case "stdout":
+			lw.Backends = append(lw.Backends, backend.NewStdoutBackend(os.Stdout))
+		case "s3":
+			b, err := backend.NewS3Backend(cfg.S3Config, cfg.Logger)
+			if err != nil {
+				return fmt.Errorf("failed to create s3 backend, bucket: %s, endpoint: %s, err: %s", cfg.S3Config.Bucket, cfg.S3Config.Endpoint, err)
+			}
+			s.run(ctx, b.Run)
+			lw.Backends = append(lw.Backends, b)
+		default:
+			return fmt.Errorf("invalid flag backend, unknown backend %s", name)
+		}
+	}
+
+	return nil
+}
+
+// configureGit resolves the branch, the credentials and the identity of the commits and prepares the repository
+func (s *Server) configureGit(ctx context.Context, k8sHost string) error {
+	cfg, lw, logger := s.cfg, s.Listener, s.cfg.Logger
+
+	failureConfig := cfg.FailureConfig
+	failureConfig.PodName, _ = os.LookupEnv("POD_NAME")
+	failureConfig.PodNamespace, _ = os.LookupEnv("POD_NAMESPACE")
+	lw.FailureConfig = failureConfig
+
+	emitsEvents := failureConfig.FailureThreshold > 0 && failureConfig.PodName != ""
+	if lw.Client == nil && (cfg.GroupByApp || cfg.AuthorMappingConfigMap != "" || cfg.BranchMappingConfigMap != "" || emitsEvents || cfg.DetectDrift || cfg.SignKeySecret != "" || cfg.CredentialProvider == "secret") {
+		c, err := newClient()
+		if err != nil {
+			return fmt.Errorf("failed to create kubernetes client, err: %s", err)
+		}
+		lw.Client = c
+	}
+
+	branch := cfg.Branch
+	if cfg.BranchMappingFile != "" || cfg.BranchMappingConfigMap != "" {
+		resolved, err := resolveBranch(lw.Client, cfg.BranchMappingFile, cfg.BranchMappingConfigMap, cfg.ClusterName, k8sHost)
+		if err != nil {
+			return fmt.Errorf("failed to resolve branch from mapping, err: %s", err)
+		}
+		if resolved != "" {
+			logger.Info("resolved branch from mapping", "branch", resolved, "clusterName", cfg.ClusterName, "host", k8sHost)
+			branch = resolved
+		}
+	}
+	if err := git.ValidateBranch(branch); err != nil {
+		return fmt.Errorf("invalid git branch, err: %s", err)
+	}
+
+	if err := listener.ValidateBranchStrategy(cfg.BranchStrategy); err != nil {
+		return fmt.Errorf("invalid flag branchStrategy, err: %s", err)
+	}
+	if cfg.BranchStrategy != "" && cfg.BranchStrategy != listener.BranchSingle && cfg.ReviewProvider != "" {
+		return fmt.Errorf("branchStrategy can't be combined with reviewProvider, pull requests are opened into a single branch")
+	}
+
+	pushOptions := cfg.PushOptions
+	pushOptions.Mirrors = append([]git.Remote{}, pushOptions.Mirrors...)
+	for _, m := range cfg.GitMirrors {
+		name, url, ok := strings.Cut(m, "=")
+		if !ok || name == "" || url == "" || name == "origin" {
+			return fmt.Errorf("invalid flag gitMirror, invalid mirror %s, expected name=url with a name other than origin", m)
+		}
+		pushOptions.Mirrors = append(pushOptions.Mirrors, git.Remote{Name: name, URL: url})
+	}
+
+	var auth transport.AuthMethod
+	switch cfg.CredentialProvider {
+	case "vault":
+		provider := vault.NewCredentialProvider(cfg.VaultConfig, logger)
+		if err := provider.Start(ctx); err != nil {
+			return fmt.Errorf("failed to get git credentials from vault, address: %s, path: %s, err: %s", cfg.VaultConfig.Address, cfg.VaultConfig.SecretPath, err)
+		}
+		auth = provider.AuthMethod()
+	case "secret":
+		namespace, name, _ := strings.Cut(cfg.GitCredentialsSecret, "/")
+		if namespace == "" || name == "" {
+			return fmt.Errorf("invalid flag gitCredentialsSecret, expected namespace/name, got %s", cfg.GitCredentialsSecret)
+		}
+		auth = git.NewProvidedAuth(git.SecretAuthProvider(lw.Client, namespace, name))
+	case "static":
+		a, err := staticAuth(cfg.GitAuthMethod)
+		if err != nil {
+			return fmt.Errorf("failed to create git auth, method: %s, err: %s", cfg.GitAuthMethod, err)
+		}
+		auth = a
+	default:
+		return fmt.Errorf("invalid flag credentialProvider, unknown credential provider %s", cfg.CredentialProvider)
+	}
+
+	lw.GitConfig = listener.GitConfig{
+		GitURL:          cfg.GitURL,
+		GitPath:         cfg.GitPath,
+		SubPath:         cfg.SubPath,
+		GitBranch:       branch,
+		BranchStrategy:  cfg.BranchStrategy,
+		GitAuth:         auth,
+		PushOptions:     pushOptions,
+		GitOpTimeout:    cfg.GitOpTimeout,
+		GroupByApp:      cfg.GroupByApp,
+		AppLabel:        cfg.AppLabel,
+		AutoRecoverRepo: cfg.AutoRecoverRepo,
+		CommitInterval:  cfg.CommitInterval,
+	}
+
+	if cfg.GroupByCluster {
+		lw.ClusterName = cfg.ClusterName
+	}
+
+	if cfg.ReviewProvider != "" {
+		provider, err := newReviewProvider(cfg.ReviewProvider, cfg.ReviewAPIURL, cfg.GitURL)
+		if err != nil {
+			return fmt.Errorf("failed to create review provider, provider: %s, err: %s", cfg.ReviewProvider, err)
+		}
+		lw.ReviewProvider = provider
+		lw.ReviewByAnnotation = cfg.ReviewByAnnotation
+
+		lw.ReviewBranch = cfg.ReviewBranch
+		if lw.ReviewBranch == "" {
+			lw.ReviewBranch = "k8s-resource-tracer/" + branch
+		}
+	}
+
+	lw.Identity = cfg.Identity
+	if cfg.AuthorMappingFile != "" {
+		authors, err := git.LoadAuthorMapping(cfg.AuthorMappingFile)
+		if err != nil {
+			return fmt.Errorf("failed to load author mapping, path: %s, err: %s", cfg.AuthorMappingFile, err)
+		}
+		lw.Identity.Authors = authors
+	} else if cfg.AuthorMappingConfigMap != "" {
+		namespace, name, _ := strings.Cut(cfg.AuthorMappingConfigMap, "/")
+		authors := git.AuthorMapping{}
+		if err := lw.Client.GetConfigMapFieldYamlUnmarshal(context.TODO(), namespace, name, AuthorMappingKey, &authors); err != nil {
+			return fmt.Errorf("failed to load author mapping, configmap: %s, err: %s", cfg.AuthorMappingConfigMap, err)
+		}
+		lw.Identity.Authors = authors
+	}
+
+	if cfg.SignCommits {
+		signKey, err := loadSignKey(lw.Client, cfg.SignKeyFile, cfg.SignKeyPassphraseFile, cfg.SignKeySecret)
+		if err != nil {
+			return fmt.Errorf("failed to load gpg key, err: %s", err)
+		}
+		if signKey == nil {
+			logger.Info("signCommits is set without signKeyFile or signKeySecret, commits are not signed")
+		}
+		lw.Identity.SignKey = signKey
+		lw.PushOptions.SignKey = signKey
+	}
+
+	if !cfg.EnableLeaderElection {
+		if err := prepareRepo(ctx, lw, logger); err != nil {
+			return fmt.Errorf("failed to prepare git repo, err: %s", err)
+		}
+	}
+
+	return nil
+}
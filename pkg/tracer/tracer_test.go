@@ -0,0 +1,34 @@
+package tracer
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+)
+
+func TestNewServerRejectsDedupFlags(t *testing.T) {
+	cases := []struct {
+		name    string
+		size    int
+		ttl     time.Duration
+		wantErr string
+	}{
+		{name: "zero cache size", size: 0, ttl: time.Minute, wantErr: "dedupCacheSize"},
+		{name: "negative cache size", size: -1, ttl: time.Minute, wantErr: "dedupCacheSize"},
+		{name: "zero ttl", size: 10, ttl: 0, wantErr: "dedupTTL"},
+		{name: "negative ttl", size: 10, ttl: -time.Second, wantErr: "dedupTTL"},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			cfg := Config{Logger: logr.Discard(), Dedup: true, DedupCacheSize: c.size, DedupTTL: c.ttl}
+			_, err := NewServer(context.Background(), cfg)
+			if err == nil || !strings.Contains(err.Error(), "invalid flag "+c.wantErr) {
+				t.Errorf("expected flag %s to be rejected, got %v", c.wantErr, err)
+			}
+		})
+	}
+}
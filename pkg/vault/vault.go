@@ -0,0 +1,319 @@
+package vault
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	githttp "github.com/go-git/go-git/v5/plumbing/transport/http"
+	"github.com/go-git/go-git/v5/plumbing/transport/ssh"
+	"github.com/go-logr/logr"
+	gossh "golang.org/x/crypto/ssh"
+)
+
+const (
+	DefaultAuthPath  = "kubernetes"
+	DefaultTokenFile = "/var/run/secrets/kubernetes.io/serviceaccount/token"
+
+	// keys read from the vault secret
+	usernameKey      = "username"
+	passwordKey      = "password"
+	sshPrivateKeyKey = "ssh_private_key"
+	sshPassphraseKey = "ssh_passphrase"
+
+	// refresh credentials when this fraction of the lease is left
+	refreshFactor    = 2.0 / 3.0
+	minRefresh       = 10 * time.Second
+	defaultRefresh   = 5 * time.Minute
+	retryOnFailure   = 30 * time.Second
+	requestTimeout   = 10 * time.Second
+	vaultTokenHeader = "X-Vault-Token"
+)
+
+type Config struct {
+	Address string
+	// Role is the vault role bound to the service account of the pod
+	Role string
+	// SecretPath is the path of the secret holding the git credentials, e.g. secret/data/git for kv v2
+	SecretPath string
+	// AuthPath is the mount path of the kubernetes auth method
+	AuthPath string
+	// TokenFile is the service account token used to log in to vault
+	TokenFile string
+}
+
+// CredentialProvider fetches git credentials from vault using the kubernetes auth method and
+// keeps both the vault token and the credentials fresh before they expire.
+type CredentialProvider struct {
+	Config
+	client *http.Client
+	logger logr.Logger
+
+	mu          sync.RWMutex
+	token       string
+	tokenExpiry time.Time
+	renewable   bool
+	auth        transport.AuthMethod
+}
+
+type authResponse struct {
+	Auth struct {
+		ClientToken   string `json:"client_token"`
+		LeaseDuration int    `json:"lease_duration"`
+		Renewable     bool   `json:"renewable"`
+	} `json:"auth"`
+}
+
+type secretResponse struct {
+	LeaseDuration int                    `json:"lease_duration"`
+	Data          map[string]interface{} `json:"data"`
+}
+
+func NewCredentialProvider(cfg Config, logger logr.Logger) *CredentialProvider {
+	if cfg.AuthPath == "" {
+		cfg.AuthPath = DefaultAuthPath
+	}
+	if cfg.TokenFile == "" {
+		cfg.TokenFile = DefaultTokenFile
+	}
+
+	return &CredentialProvider{
+		Config: cfg,
+		client: &http.Client{Timeout: requestTimeout},
+		logger: logger.WithName("vault"),
+	}
+}
+
+// Start fetches the credentials once and keeps refreshing them in the background until ctx is done
+func (p *CredentialProvider) Start(ctx context.Context) error {
+	next, err := p.refresh(ctx)
+	if err != nil {
+		return err
+	}
+
+	go func() {
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(next):
+			}
+
+			if next, err = p.refresh(ctx); err != nil {
+				p.logger.Error(err, "failed to refresh git credentials from vault", "retryIn", retryOnFailure)
+				next = retryOnFailure
+			}
+		}
+	}()
+
+	return nil
+}
+
+// AuthMethod returns an auth method which always uses the latest credentials fetched from vault,
+// it works for both http and ssh remotes
+func (p *CredentialProvider) AuthMethod() transport.AuthMethod {
+	return &refreshingAuth{provider: p}
+}
+
+func (p *CredentialProvider) current() transport.AuthMethod {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.auth
+}
+
+// refresh renews or re-creates the vault token, reads the credentials and returns when to refresh next
+func (p *CredentialProvider) refresh(ctx context.Context) (time.Duration, error) {
+	if err := p.ensureToken(ctx); err != nil {
+		return 0, err
+	}
+
+	secret := secretResponse{}
+	if err := p.do(ctx, http.MethodGet, p.SecretPath, nil, &secret); err != nil {
+		return 0, fmt.Errorf("failed to read secret, path: %s, err: %s", p.SecretPath, err)
+	}
+
+	data := secret.Data
+	// kv v2 nests the secret under data.data
+	if nested, ok := data["data"].(map[string]interface{}); ok {
+		data = nested
+	}
+
+	auth, err := authFromSecret(data)
+	if err != nil {
+		return 0, fmt.Errorf("invalid secret, path: %s, err: %s", p.SecretPath, err)
+	}
+
+	p.mu.Lock()
+	p.auth = auth
+	tokenTTL := time.Until(p.tokenExpiry)
+	p.mu.Unlock()
+
+	p.logger.V(1).Info("git credentials refreshed", "path", p.SecretPath)
+
+	next := defaultRefresh
+	if tokenTTL > 0 && tokenTTL < next {
+		next = tokenTTL
+	}
+	if lease := time.Duration(secret.LeaseDuration) * time.Second; lease > 0 && lease < next {
+		next = lease
+	}
+	next = time.Duration(float64(next) * refreshFactor)
+	if next < minRefresh {
+		next = minRefresh
+	}
+
+	return next, nil
+}
+
+func (p *CredentialProvider) ensureToken(ctx context.Context) error {
+	p.mu.RLock()
+	token, expiry, renewable := p.token, p.tokenExpiry, p.renewable
+	p.mu.RUnlock()
+
+	if token != "" && time.Now().Before(expiry) {
+		if !renewable {
+			return nil
+		}
+
+		resp := authResponse{}
+		err := p.do(ctx, http.MethodPost, "auth/token/renew-self", nil, &resp)
+		if err == nil {
+			p.setToken(resp)
+			return nil
+		}
+		p.logger.Info("failed to renew vault token, logging in again", "reason", err.Error())
+	}
+
+	jwt, err := os.ReadFile(p.TokenFile)
+	if err != nil {
+		return fmt.Errorf("failed to read service account token, path: %s, err: %s", p.TokenFile, err)
+	}
+
+	body := map[string]string{"role": p.Role, "jwt": strings.TrimSpace(string(jwt))}
+	resp := authResponse{}
+	if err := p.do(ctx, http.MethodPost, fmt.Sprintf("auth/%s/login", p.AuthPath), body, &resp); err != nil {
+		return fmt.Errorf("failed to log in to vault, role: %s, err: %s", p.Role, err)
+	}
+	p.setToken(resp)
+
+	return nil
+}
+
+func (p *CredentialProvider) setToken(resp authResponse) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.token = resp.Auth.ClientToken
+	p.renewable = resp.Auth.Renewable
+	if resp.Auth.LeaseDuration > 0 {
+		p.tokenExpiry = time.Now().Add(time.Duration(resp.Auth.LeaseDuration) * time.Second)
+	} else {
+		// root-like tokens never expire
+		p.tokenExpiry = time.Now().Add(24 * 365 * time.Hour)
+	}
+}
+
+func (p *CredentialProvider) do(ctx context.Context, method, path string, body, out interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	url := fmt.Sprintf("%s/v1/%s", strings.TrimSuffix(p.Address, "/"), strings.TrimPrefix(path, "/"))
+	req, err := http.NewRequestWithContext(ctx, method, url, reader)
+	if err != nil {
+		return err
+	}
+
+	p.mu.RLock()
+	if p.token != "" {
+		req.Header.Set(vaultTokenHeader, p.token)
+	}
+	p.mu.RUnlock()
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %d from %s: %s", resp.StatusCode, path, strings.TrimSpace(string(data)))
+	}
+
+	return json.Unmarshal(data, out)
+}
+
+func authFromSecret(data map[string]interface{}) (transport.AuthMethod, error) {
+	str := func(key string) string {
+		v, _ := data[key].(string)
+		return v
+	}
+
+	if key := str(sshPrivateKeyKey); key != "" {
+		user := str(usernameKey)
+		if user == "" {
+			user = ssh.DefaultUsername
+		}
+		return ssh.NewPublicKeys(user, []byte(key), str(sshPassphraseKey))
+	}
+
+	if str(usernameKey) == "" && str(passwordKey) == "" {
+		return nil, fmt.Errorf("neither %s nor %s/%s is set", sshPrivateKeyKey, usernameKey, passwordKey)
+	}
+
+	return &githttp.BasicAuth{
+		Username: str(usernameKey),
+		Password: str(passwordKey),
+	}, nil
+}
+
+// refreshingAuth delegates to the credentials currently held by the provider, so that the auth method
+// captured at startup stays valid after the credentials are rotated
+type refreshingAuth struct {
+	provider *CredentialProvider
+}
+
+func (a *refreshingAuth) Name() string {
+	if auth := a.provider.current(); auth != nil {
+		return auth.Name()
+	}
+	return "vault"
+}
+
+func (a *refreshingAuth) String() string {
+	return fmt.Sprintf("vault: %s", a.Name())
+}
+
+// SetAuth implements http.AuthMethod
+func (a *refreshingAuth) SetAuth(r *http.Request) {
+	if auth, ok := a.provider.current().(githttp.AuthMethod); ok {
+		auth.SetAuth(r)
+	}
+}
+
+// ClientConfig implements ssh.AuthMethod
+func (a *refreshingAuth) ClientConfig() (*gossh.ClientConfig, error) {
+	auth, ok := a.provider.current().(ssh.AuthMethod)
+	if !ok {
+		return nil, transport.ErrInvalidAuthMethod
+	}
+	return auth.ClientConfig()
+}
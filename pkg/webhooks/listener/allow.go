@@ -0,0 +1,31 @@
+package listener
+
+// identityPaths are kept by the allowlist regardless of AllowPaths, they identify the object and its last manager
+var identityPaths = mustParsePaths("apiVersion", "kind", "metadata.name", "metadata.namespace", "metadata.uid",
+	"metadata.managedFields", "metadata.ownerReferences")
+
+// allowOnly reduces obj to the allowed paths, changes anywhere else are neither diffed nor stored
+func (l *ListenerWebhook) allowOnly(obj map[string]interface{}) map[string]interface{} {
+	projected := map[string]interface{}{}
+	for _, p := range identityPaths {
+		p.Project(obj, projected)
+	}
+	for _, p := range l.AllowPaths {
+		p.Project(obj, projected)
+	}
+
+	return projected
+}
+
+func mustParsePaths(raw ...string) []Path {
+	paths := make([]Path, 0, len(raw))
+	for _, r := range raw {
+		p, err := ParsePath(r)
+		if err != nil {
+			panic(err)
+		}
+		paths = append(paths, p)
+	}
+
+	return paths
+}
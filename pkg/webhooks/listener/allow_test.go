@@ -0,0 +1,75 @@
+package listener
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	admissionv1 "k8s.io/api/admission/v1"
+)
+
+func TestAllowPaths(t *testing.T) {
+	path := filepath.Join("default", "apps-v1.Deployment", "app.yaml")
+	old := deployment(map[string]interface{}{"replicas": int64(1), "paused": false, "password": "hunter1"})
+
+	cases := []struct {
+		name    string
+		spec    map[string]interface{}
+		commit  bool
+		stored  []string
+		dropped []string
+	}{
+		{name: "change outside the allowlist", spec: map[string]interface{}{"replicas": int64(1), "paused": true, "password": "hunter1"}},
+		{name: "allowed change", spec: map[string]interface{}{"replicas": int64(2), "paused": true, "password": "hunter1"}, commit: true,
+			stored: []string{"replicas: 2"}, dropped: []string{"paused"}},
+		{name: "redacted allowed path", spec: map[string]interface{}{"replicas": int64(3), "paused": false, "password": "hunter2"}, commit: true,
+			stored: []string{"password: " + RedactedValue}, dropped: []string{"hunter", "paused"}},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			l, repo, _ := gitListener(t)
+			l.AllowPaths = mustParsePaths("spec.replicas", "spec.password")
+			l.RedactPaths = mustParsePaths("spec.password")
+
+			r := request(t, admissionv1.Update, old, deployment(c.spec))
+			if resp := l.handle(context.Background(), r); !resp.Allowed {
+				t.Fatalf("expected request to be allowed, got %v", resp.Result)
+			}
+
+			head, err := repo.Head()
+			if !c.commit {
+				if err == nil {
+					t.Errorf("expected no commit, got %s", head.Hash())
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expected a commit, got %s", err)
+			}
+			commit, err := repo.CommitObject(head.Hash())
+			if err != nil {
+				t.Fatal(err)
+			}
+			f, err := commit.File(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			contents, err := f.Contents()
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, s := range c.stored {
+				if !strings.Contains(contents, s) {
+					t.Errorf("expected %q to be stored, got %q", s, contents)
+				}
+			}
+			for _, s := range c.dropped {
+				if strings.Contains(contents, s) {
+					t.Errorf("expected %q to be dropped, got %q", s, contents)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,39 @@
+package listener
+
+import (
+	"context"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// maxOwnerDepth bounds the owner chain walk, e.g. Pod -> ReplicaSet -> Deployment
+const maxOwnerDepth = 10
+
+// resolveApp walks up the controller owner chain of obj and returns the value of the
+// app label from the first object carrying it, or an empty string if none was found.
+func (l *ListenerWebhook) resolveApp(ctx context.Context, obj map[string]interface{}) string {
+	current := &unstructured.Unstructured{Object: obj}
+	for i := 0; i < maxOwnerDepth; i++ {
+		if app := current.GetLabels()[l.AppLabel]; app != "" {
+			return app
+		}
+
+		ref := metav1.GetControllerOfNoCopy(current)
+		if ref == nil || l.Client == nil {
+			return ""
+		}
+
+		owner := &unstructured.Unstructured{}
+		owner.SetAPIVersion(ref.APIVersion)
+		owner.SetKind(ref.Kind)
+		if err := l.Client.Get(ctx, types.NamespacedName{Namespace: current.GetNamespace(), Name: ref.Name}, owner); err != nil {
+			l.Logger.Error(err, "failed to get owner", "kind", ref.Kind, "name", ref.Name, "namespace", current.GetNamespace())
+			return ""
+		}
+		current = owner
+	}
+
+	return ""
+}
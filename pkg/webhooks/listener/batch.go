@@ -0,0 +1,119 @@
+package listener
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/reborn1867/k8s-resource-tracer/pkg/git"
+	"github.com/reborn1867/k8s-resource-tracer/pkg/metrics"
+)
+
+// batchAuthor authors batches of changes made by more than one user
+const batchAuthor = "k8s-resource-tracer"
+
+// stage writes the change to the work tree and the index, it is committed by the next flush of the batch
+func (l *ListenerWebhook) stage(c change) error {
+	if err := l.stageHistory(c); err != nil {
+		return err
+	}
+
+	switch {
+	case c.delete:
+		if err := git.StageRemoval(l.GitPath, c.subpath, l.Logger); err != nil {
+			return fmt.Errorf("failed to stage removal: %s", err)
+		}
+	case c.touch:
+		// nothing to write, the touch is listed in the message of the batch
+	default:
+		if err := git.StageChange(l.GitPath, c.subpath, c.data, l.Logger); err != nil {
+			return fmt.Errorf("failed to stage new object: %s", err)
+		}
+	}
+
+	l.pending = append(l.pending, c)
+
+	return nil
+}
+
+// commitBatch commits the staged changes as one commit listing all of them, the caller holds gitMu
+func (l *ListenerWebhook) commitBatch() error {
+	if len(l.pending) == 0 {
+		return nil
+	}
+
+	user := l.pending[0].user
+	lines := make([]string, 0, len(l.pending))
+	var trailers []git.Trailer
+	for _, c := range l.pending {
+		if c.user != user {
+			user = batchAuthor
+		}
+
+		line := c.subject
+		if line == "" {
+			action := "changed"
+			if c.delete {
+				action = "deleted"
+			} else if c.touch {
+				action = "touched"
+			} else if c.created {
+				action = "created"
+			}
+			line = fmt.Sprintf("%s %s by %s, field manager: %s", c.subpath, action, c.user, c.fieldManager)
+		}
+		lines = append(lines, "- "+line)
+		trailers = append(trailers, c.trailers...)
+	}
+
+	message := fmt.Sprintf("%d changes by %s\n\n%s", len(l.pending), user, strings.Join(lines, "\n"))
+	if err := git.CommitStaged(l.GitPath, user, message, trailers, l.Identity); err != nil {
+		metrics.GitCommits.WithLabelValues(metrics.ResultFailure).Inc()
+		return fmt.Errorf("failed to commit batch: %s", err)
+	}
+	metrics.GitCommits.WithLabelValues(metrics.ResultSuccess).Inc()
+	l.Logger.Info("git commit of batch successfully", "changes", len(l.pending), "author", user)
+	l.pending = nil
+
+	return nil
+}
+
+// flushBatch commits and pushes the staged changes
+func (l *ListenerWebhook) flushBatch(ctx context.Context) error {
+	l.gitMu.Lock()
+	defer l.gitMu.Unlock()
+
+	if len(l.pending) == 0 {
+		return nil
+	}
+
+	if err := l.commitBatch(); err != nil {
+		return err
+	}
+
+	return l.push(ctx)
+}
+
+// RunCommitter commits and pushes the changes staged since the last interval until ctx is done, then flushes
+// the last batch before returning
+func (l *ListenerWebhook) RunCommitter(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			// ctx is done already, the last push must not be cancelled by it
+			if err := l.flushBatch(context.Background()); err != nil {
+				l.Logger.Error(err, "failed to flush batch on shutdown")
+			}
+			return
+		case <-ticker.C:
+		}
+
+		if err := l.flushBatch(ctx); err != nil {
+			l.Logger.Error(err, "failed to flush batch")
+		}
+	}
+}
@@ -0,0 +1,31 @@
+package listener
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+)
+
+// summarizeBinaryData replaces the values of binaryData in configmaps by their size and hash, diffing base64
+// blobs as text is meaningless and bloats storage
+func summarizeBinaryData(obj map[string]interface{}) {
+	if obj["apiVersion"] != "v1" || obj["kind"] != "ConfigMap" {
+		return
+	}
+
+	binaryData, _ := obj["binaryData"].(map[string]interface{})
+	for k, v := range binaryData {
+		encoded, ok := v.(string)
+		if !ok {
+			continue
+		}
+
+		data, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			data = []byte(encoded)
+		}
+		sum := sha256.Sum256(data)
+		binaryData[k] = fmt.Sprintf("sha256:%s,size=%d", hex.EncodeToString(sum[:]), len(data))
+	}
+}
@@ -0,0 +1,71 @@
+package listener
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/reborn1867/k8s-resource-tracer/pkg/git"
+)
+
+// Branch strategies deciding which branch a change is committed to
+const (
+	BranchSingle       = "single"
+	BranchPerNamespace = "per-namespace"
+	BranchPerGVK       = "per-gvk"
+
+	// clusterScope names the branch of cluster scoped objects with BranchPerNamespace
+	clusterScope = "cluster"
+)
+
+// ValidateBranchStrategy returns an error for unknown strategies, empty means BranchSingle
+func ValidateBranchStrategy(strategy string) error {
+	switch strategy {
+	case "", BranchSingle, BranchPerNamespace, BranchPerGVK:
+		return nil
+	}
+	return fmt.Errorf("unknown branch strategy %s, expected %s, %s or %s", strategy, BranchSingle, BranchPerNamespace, BranchPerGVK)
+}
+
+// branchFor returns the branch changes of objects of gvk in namespace are committed to. Branches are named
+// <GitBranch>-<namespace> or <GitBranch>-<gvk>, git can't keep <GitBranch>/<namespace> next to <GitBranch>.
+func (l *ListenerWebhook) branchFor(namespace, gvk string) string {
+	switch l.BranchStrategy {
+	case BranchPerNamespace:
+		if namespace == "" {
+			namespace = clusterScope
+		}
+		return fmt.Sprintf("%s-%s", l.GitBranch, namespace)
+	case BranchPerGVK:
+		return fmt.Sprintf("%s-%s", l.GitBranch, gvk)
+	}
+	return l.GitBranch
+}
+
+// syncBranch commits and pushes c on its own branch and switches back to GitBranch afterwards
+func (l *ListenerWebhook) syncBranch(ctx context.Context, c change) (err error) {
+	if err := git.CheckoutBranch(ctx, l.GitPath, c.branch, l.GitAuth, l.Logger); err != nil {
+		return fmt.Errorf("failed to checkout branch %s: %s", c.branch, err)
+	}
+	defer func() {
+		if switchErr := git.SwitchBranch(l.GitPath, l.GitBranch); switchErr != nil && err == nil {
+			err = fmt.Errorf("failed to switch back to branch %s: %s", l.GitBranch, switchErr)
+		}
+	}()
+
+	if err := l.commit(c); err != nil {
+		return err
+	}
+
+	return l.push(ctx)
+}
+
+// targetBranch is the branch c is committed to by syncGit
+func (l *ListenerWebhook) targetBranch(c change) string {
+	switch {
+	case l.ReviewProvider != nil && (!l.ReviewByAnnotation || c.requiresApproval):
+		return l.ReviewBranch
+	case c.branch != "":
+		return c.branch
+	}
+	return l.GitBranch
+}
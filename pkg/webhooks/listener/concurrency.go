@@ -0,0 +1,46 @@
+package listener
+
+import (
+	"context"
+	"time"
+
+	"github.com/reborn1867/k8s-resource-tracer/pkg/metrics"
+)
+
+// DefaultHandlerWait is how long a request waits for a free handler before it is admitted untraced
+const DefaultHandlerWait = time.Second
+
+// acquire takes one of MaxConcurrentHandlers slots, waiting at most HandlerWait. It reports false when the limit is
+// reached, in which case the request is admitted without being traced.
+func (l *ListenerWebhook) acquire(ctx context.Context) bool {
+	if l.MaxConcurrentHandlers <= 0 {
+		return true
+	}
+
+	l.semOnce.Do(func() {
+		l.sem = make(chan struct{}, l.MaxConcurrentHandlers)
+	})
+
+	timer := time.NewTimer(l.HandlerWait)
+	defer timer.Stop()
+
+	select {
+	case l.sem <- struct{}{}:
+		metrics.InflightHandlers.Inc()
+		return true
+	case <-timer.C:
+	case <-ctx.Done():
+	}
+
+	metrics.SaturatedHandlers.Inc()
+	return false
+}
+
+func (l *ListenerWebhook) release() {
+	if l.MaxConcurrentHandlers <= 0 {
+		return
+	}
+
+	<-l.sem
+	metrics.InflightHandlers.Dec()
+}
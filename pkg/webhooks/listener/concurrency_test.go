@@ -0,0 +1,98 @@
+package listener
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	admissionv1 "k8s.io/api/admission/v1"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+func TestGateBoundsConcurrency(t *testing.T) {
+	const limit, requests = 3, 50
+	l := newTestListener()
+	l.MaxConcurrentHandlers = limit
+	l.HandlerWait = time.Minute
+
+	var inflight, peak atomic.Int32
+	trace := func(ctx context.Context, r admission.Request) admission.Response {
+		n := inflight.Add(1)
+		for {
+			p := peak.Load()
+			if n <= p || peak.CompareAndSwap(p, n) {
+				break
+			}
+		}
+		time.Sleep(5 * time.Millisecond)
+		inflight.Add(-1)
+		return admission.Allowed("allowed")
+	}
+
+	r := request(t, admissionv1.Update, deployment(nil), deployment(nil))
+	var wg sync.WaitGroup
+	var traced atomic.Int32
+	for i := 0; i < requests; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if resp, ok := l.gate(context.Background(), r, trace); ok && resp.Allowed {
+				traced.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if p := peak.Load(); p > limit {
+		t.Errorf("expected at most %d requests traced at once, got %d", limit, p)
+	}
+	if n := traced.Load(); n != requests {
+		t.Errorf("expected all %d requests to be traced after waiting, got %d", requests, n)
+	}
+}
+
+func TestGateAdmitsUntracedWhenSaturated(t *testing.T) {
+	l := newTestListener()
+	l.MaxConcurrentHandlers = 1
+	l.HandlerWait = 10 * time.Millisecond
+
+	r := request(t, admissionv1.Update, deployment(nil), deployment(nil))
+	started, unblock := make(chan struct{}), make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		l.gate(context.Background(), r, func(ctx context.Context, r admission.Request) admission.Response {
+			close(started)
+			<-unblock
+			return admission.Allowed("allowed")
+		})
+	}()
+	<-started
+
+	untraced := func(ctx context.Context, r admission.Request) admission.Response {
+		t.Error("expected the saturated request not to be traced")
+		return admission.Allowed("allowed")
+	}
+	resp, traced := l.gate(context.Background(), r, untraced)
+	if traced || !resp.Allowed {
+		t.Errorf("expected the request to be admitted untraced, got traced %v, allowed %v", traced, resp.Allowed)
+	}
+
+	// a cancelled request doesn't wait for the handler wait
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	l.HandlerWait = time.Minute
+	if _, traced := l.gate(ctx, r, untraced); traced {
+		t.Error("expected the cancelled request not to be traced")
+	}
+
+	close(unblock)
+	<-done
+	if _, traced := l.gate(context.Background(), r, func(ctx context.Context, r admission.Request) admission.Response {
+		return admission.Allowed("allowed")
+	}); !traced {
+		t.Error("expected the released slot to be reused")
+	}
+}
@@ -0,0 +1,29 @@
+package listener
+
+import (
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+const conversionTrailer = "Version-Conversion"
+
+// versionConversion compares the apiVersions of both objects and returns a note when they differ, the noise of a
+// conversion can't be removed without the api server so the diff is labeled instead. Within the same group the old
+// apiVersion is aligned to the new one, other fields are left as they are.
+func versionConversion(oldObj, obj map[string]interface{}) string {
+	oldVersion, _ := oldObj["apiVersion"].(string)
+	newVersion, _ := obj["apiVersion"].(string)
+	if oldVersion == "" || oldVersion == newVersion {
+		return ""
+	}
+
+	oldGV, oldErr := schema.ParseGroupVersion(oldVersion)
+	newGV, newErr := schema.ParseGroupVersion(newVersion)
+	if oldErr != nil || newErr != nil || oldGV.Group != newGV.Group {
+		return fmt.Sprintf("%s -> %s, not convertible, the diff compares different apis", oldVersion, newVersion)
+	}
+
+	oldObj["apiVersion"] = newVersion
+	return fmt.Sprintf("%s -> %s, the diff may contain changes introduced by the conversion", oldVersion, newVersion)
+}
@@ -0,0 +1,55 @@
+package listener
+
+import (
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/cache"
+)
+
+const (
+	DefaultDedupCacheSize = 10000
+	DefaultDedupTTL       = 10 * time.Minute
+)
+
+// Dedup remembers the content hashes of the last two states committed for every object, so that changes back and
+// forth between them, e.g. of two controllers fighting over a field, are committed once and skipped afterwards
+type Dedup struct {
+	cache *cache.LRUExpireCache
+	ttl   time.Duration
+}
+
+// committedStates are the content hashes of the last and the previous committed state of an object
+type committedStates struct {
+	last     string
+	previous string
+}
+
+// NewDedup creates a cache of at most size objects, entries expire ttl after the last commit of the object
+func NewDedup(size int, ttl time.Duration) *Dedup {
+	return &Dedup{cache: cache.NewLRUExpireCache(size), ttl: ttl}
+}
+
+// Seen reports whether hash is one of the last two states committed for the object at key
+func (d *Dedup) Seen(key, hash string) bool {
+	v, ok := d.cache.Get(key)
+	if !ok {
+		return false
+	}
+
+	states := v.(committedStates)
+	return hash == states.last || hash == states.previous
+}
+
+// Committed records hash as the last committed state of the object at key
+func (d *Dedup) Committed(key, hash string) {
+	states := committedStates{last: hash}
+	if v, ok := d.cache.Get(key); ok {
+		states.previous = v.(committedStates).last
+	}
+	d.cache.Add(key, states, d.ttl)
+}
+
+// Forget drops the object at key, e.g. once it is deleted
+func (d *Dedup) Forget(key string) {
+	d.cache.Remove(key)
+}
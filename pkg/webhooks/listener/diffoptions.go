@@ -0,0 +1,51 @@
+package listener
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	jd "github.com/josephburnett/jd/lib"
+)
+
+// Diff options accepted by ParseDiffOption
+const (
+	DiffOptionSet       = "set"
+	DiffOptionMultiset  = "multiset"
+	DiffOptionPrecision = "precision"
+)
+
+// CustomRenderOption is jd metadata passed to every diff. Rendering only knows jd.COLOR, which follows the output
+// format, and jd.RenderOption can't be implemented outside of jd, so the options only change what is diffed.
+type CustomRenderOption struct {
+	jd.Metadata
+}
+
+// ParseDiffOption parses set, multiset or precision=<number>. set and multiset diff all lists regardless of the
+// order of their items, precision treats numbers as equal which differ by less than it.
+func ParseDiffOption(raw string) (CustomRenderOption, error) {
+	name, value, _ := strings.Cut(raw, "=")
+	switch name {
+	case DiffOptionSet:
+		return CustomRenderOption{jd.SET}, nil
+	case DiffOptionMultiset:
+		return CustomRenderOption{jd.MULTISET}, nil
+	case DiffOptionPrecision:
+		precision, err := strconv.ParseFloat(value, 64)
+		if err != nil || precision <= 0 || math.IsNaN(precision) || math.IsInf(precision, 0) {
+			return CustomRenderOption{}, fmt.Errorf("invalid precision %s, expected a positive finite number", value)
+		}
+		return CustomRenderOption{jd.SetPrecision(precision)}, nil
+	}
+	return CustomRenderOption{}, fmt.Errorf("unknown diff option %s, expected %s, %s or %s=<number>", raw, DiffOptionSet, DiffOptionMultiset, DiffOptionPrecision)
+}
+
+// diffMetadata is the jd metadata of DiffOptions, jd compares metadata by value so the options are unwrapped
+func (l *ListenerWebhook) diffMetadata() []jd.Metadata {
+	metadata := make([]jd.Metadata, 0, len(l.DiffOptions))
+	for _, o := range l.DiffOptions {
+		metadata = append(metadata, o.Metadata)
+	}
+	return metadata
+}
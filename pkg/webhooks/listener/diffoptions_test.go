@@ -0,0 +1,70 @@
+package listener
+
+import (
+	"testing"
+)
+
+func TestParseDiffOption(t *testing.T) {
+	cases := []struct {
+		raw     string
+		wantErr bool
+	}{
+		{raw: "set"},
+		{raw: "multiset"},
+		{raw: "precision=0.001"},
+		{raw: "precision=2"},
+		{raw: "precision=0", wantErr: true},
+		{raw: "precision=-1", wantErr: true},
+		{raw: "precision=NaN", wantErr: true},
+		{raw: "precision=Inf", wantErr: true},
+		{raw: "precision=+Inf", wantErr: true},
+		{raw: "precision=", wantErr: true},
+		{raw: "precision", wantErr: true},
+		{raw: "sets", wantErr: true},
+	}
+
+	for _, c := range cases {
+		t.Run(c.raw, func(t *testing.T) {
+			if _, err := ParseDiffOption(c.raw); (err != nil) != c.wantErr {
+				t.Errorf("expected error %v, got %v", c.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestDiffOptions(t *testing.T) {
+	cases := []struct {
+		name     string
+		option   string
+		old, new interface{}
+		changed  bool
+	}{
+		{name: "reordered list", old: []interface{}{"a", "b"}, new: []interface{}{"b", "a"}, changed: true},
+		{name: "reordered list as set", option: "set", old: []interface{}{"a", "b"}, new: []interface{}{"b", "a"}},
+		{name: "duplicate item as set", option: "set", old: []interface{}{"a", "b"}, new: []interface{}{"a", "b", "b"}},
+		{name: "duplicate item as multiset", option: "multiset", old: []interface{}{"a", "b"}, new: []interface{}{"a", "b", "b"}, changed: true},
+		{name: "number within precision", option: "precision=0.01", old: 1.0, new: 1.001},
+		{name: "number beyond precision", option: "precision=0.01", old: 1.0, new: 1.1, changed: true},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			l := newTestListener()
+			if c.option != "" {
+				o, err := ParseDiffOption(c.option)
+				if err != nil {
+					t.Fatal(err)
+				}
+				l.DiffOptions = []CustomRenderOption{o}
+			}
+
+			d, err := l.diff(nil, map[string]interface{}{"v": c.old}, map[string]interface{}{"v": c.new})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if changed := len(d) > 0; changed != c.changed {
+				t.Errorf("expected changed %v, got %s", c.changed, l.renderDiff(d))
+			}
+		})
+	}
+}
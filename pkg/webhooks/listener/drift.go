@@ -0,0 +1,100 @@
+package listener
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/yaml"
+
+	"github.com/reborn1867/k8s-resource-tracer/pkg/metrics"
+)
+
+const driftReason = "DriftDetected"
+
+// detectDrift compares obj with the version stored in git at the configured paths, spec by default, and reports
+// the paths which differ with a metric and an event on the object
+func (l *ListenerWebhook) detectDrift(ctx context.Context, obj map[string]interface{}, gvk string) {
+	subpath := l.storagePath(ctx, obj, gvk)
+	drifted := l.driftedPaths(subpath, obj)
+	if len(drifted) == 0 {
+		return
+	}
+
+	metrics.DriftDetected.WithLabelValues(gvk).Inc()
+
+	metadata, _ := obj["metadata"].(map[string]interface{})
+	name, _ := metadata["name"].(string)
+	namespace, _ := metadata["namespace"].(string)
+	l.Logger.Info("object drifted from git", "gvk", gvk, "name", name, "namespace", namespace, "paths", drifted)
+
+	apiVersion, _ := obj["apiVersion"].(string)
+	kind, _ := obj["kind"].(string)
+	uid, _ := metadata["uid"].(string)
+	l.emitEvent(ctx, corev1.ObjectReference{
+		APIVersion: apiVersion,
+		Kind:       kind,
+		Name:       name,
+		Namespace:  namespace,
+		UID:        types.UID(uid),
+	}, driftReason, fmt.Sprintf("differs from %s in git at %s", subpath, strings.Join(drifted, ", ")))
+}
+
+// driftedPaths returns the paths at which obj differs from the version stored at subpath, obj is normalized like
+// stored objects first so that e.g. redacted paths don't drift
+func (l *ListenerWebhook) driftedPaths(subpath string, obj map[string]interface{}) []string {
+	l.gitMu.Lock()
+	data, err := os.ReadFile(filepath.Join(l.GitPath, subpath))
+	l.gitMu.Unlock()
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		l.Logger.Error(err, "failed to read stored object for drift detection", "path", subpath)
+		return nil
+	}
+
+	stored := map[string]interface{}{}
+	if err := yaml.Unmarshal(data, &stored); err != nil {
+		l.Logger.Error(err, "failed to parse stored object for drift detection", "path", subpath)
+		return nil
+	}
+
+	live := runtime.DeepCopyJSON(obj)
+	l.prepareStored(live)
+
+	paths := l.DriftPaths
+	if len(paths) == 0 {
+		spec, _ := ParsePath("spec")
+		paths = []Path{spec}
+	}
+
+	var drifted []string
+	for _, p := range paths {
+		if !reflect.DeepEqual(collect(p, stored), collect(p, live)) {
+			drifted = append(drifted, p.String())
+		}
+	}
+	return drifted
+}
+
+// collect returns the json of every value matched by p, sorted as wildcards over maps match in random order
+func collect(p Path, obj map[string]interface{}) []string {
+	var values []string
+	p.Apply(obj, func(v interface{}) interface{} {
+		data, _ := json.Marshal(v)
+		values = append(values, string(data))
+		return v
+	})
+	sort.Strings(values)
+
+	return values
+}
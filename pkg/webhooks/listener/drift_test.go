@@ -0,0 +1,63 @@
+package listener
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+func TestDriftedPathsNormalizesLiveObject(t *testing.T) {
+	p, err := ParsePath("spec.password")
+	if err != nil {
+		t.Fatal(err)
+	}
+	l := newTestListener()
+	l.GitPath = t.TempDir()
+	l.RedactPaths = []Path{p}
+
+	stored := deployment(map[string]interface{}{"password": "hunter2", "replicas": int64(1)})
+	gvk := buildGVK(stored)
+	subpath := l.storagePath(context.Background(), stored, gvk)
+	prepared := runtime.DeepCopyJSON(stored)
+	l.prepareStored(prepared)
+	if err := os.MkdirAll(filepath.Dir(filepath.Join(l.GitPath, subpath)), os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(l.GitPath, subpath), l.encodeStored(prepared, gvk, "app", "default"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		name string
+		spec map[string]interface{}
+		want []string
+	}{
+		{name: "same object", spec: map[string]interface{}{"password": "hunter2", "replicas": int64(1)}},
+		{name: "redacted path only", spec: map[string]interface{}{"password": "hunter3", "replicas": int64(1)}},
+		{name: "spec changed", spec: map[string]interface{}{"password": "hunter2", "replicas": int64(2)}, want: []string{"spec"}},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			live := deployment(c.spec)
+			if got := l.driftedPaths(subpath, live); !reflect.DeepEqual(got, c.want) {
+				t.Errorf("expected drift at %v, got %v", c.want, got)
+			}
+			if live["spec"].(map[string]interface{})["password"] == RedactedValue {
+				t.Error("expected the live object to be left unchanged")
+			}
+		})
+	}
+}
+
+func TestDriftedPathsNotStored(t *testing.T) {
+	l := newTestListener()
+	l.GitPath = t.TempDir()
+	if got := l.driftedPaths("default/apps-v1.Deployment/app.yaml", deployment(nil)); len(got) != 0 {
+		t.Errorf("expected no drift of an object never stored, got %v", got)
+	}
+}
@@ -0,0 +1,37 @@
+package listener
+
+import (
+	"context"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// emitEvent records a warning event on the referenced object, it is a no-op without a client
+func (l *ListenerWebhook) emitEvent(ctx context.Context, ref corev1.ObjectReference, reason, message string) {
+	if l.Client == nil {
+		return
+	}
+
+	now := metav1.Now()
+	event := &corev1.Event{
+		ObjectMeta: metav1.ObjectMeta{
+			GenerateName: ref.Name + ".",
+			Namespace:    ref.Namespace,
+		},
+		InvolvedObject: ref,
+		Reason:         reason,
+		Message:        message,
+		Type:           corev1.EventTypeWarning,
+		Source:         corev1.EventSource{Component: "k8s-resource-tracer"},
+		FirstTimestamp: now,
+		LastTimestamp:  now,
+		Count:          1,
+	}
+	if event.Namespace == "" {
+		event.Namespace = metav1.NamespaceDefault
+	}
+	if err := l.Client.Create(ctx, event); err != nil {
+		l.Logger.Error(err, "failed to emit event", "reason", reason, "object", ref.Name)
+	}
+}
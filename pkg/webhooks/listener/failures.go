@@ -0,0 +1,111 @@
+package listener
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/reborn1867/k8s-resource-tracer/pkg/metrics"
+)
+
+const persistentFailureReason = "PersistentGitFailure"
+
+// FailureConfig controls the alerting on consecutive git failures
+type FailureConfig struct {
+	// FailureThreshold is the number of consecutive failures after which an event is emitted, zero disables it
+	FailureThreshold int
+	// SuspendOnFailure skips git for this duration once the threshold is reached, zero keeps trying on every change
+	SuspendOnFailure time.Duration
+	// PodName and PodNamespace identify the pod of the tracer events are emitted on
+	PodName      string
+	PodNamespace string
+}
+
+type failureTracker struct {
+	mu             sync.Mutex
+	consecutive    int
+	suspendedUntil time.Time
+}
+
+// syncGitWithTimeout bounds the network operations of syncGit by GitOpTimeout, the request context bounds them
+// anyway. Local commits are made regardless and pushed with the next change.
+func (l *ListenerWebhook) syncGitWithTimeout(ctx context.Context, c change) error {
+	if l.GitOpTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, l.GitOpTimeout)
+		defer cancel()
+	}
+
+	err := l.syncGit(ctx, c)
+	if err != nil && ctx.Err() != nil {
+		l.Logger.Info("git operation did not finish in time, admitting the request", "subpath", c.subpath, "timeout", l.GitOpTimeout, "reason", ctx.Err().Error())
+		return fmt.Errorf("git operation aborted: %s, err: %s", ctx.Err(), err)
+	}
+
+	return err
+}
+
+// sync runs syncGit unless git is suspended and keeps track of consecutive failures
+func (l *ListenerWebhook) sync(ctx context.Context, c change) error {
+	if l.DryRun {
+		l.Logger.Info("dry run, change is not committed", "subpath", c.subpath, "branch", l.targetBranch(c), "subject", c.subject,
+			"user", c.user, "fieldManager", c.fieldManager, "delete", c.delete, "touch", c.touch)
+		return nil
+	}
+
+	l.failures.mu.Lock()
+	suspended := time.Now().Before(l.failures.suspendedUntil)
+	l.failures.mu.Unlock()
+	if suspended {
+		l.Logger.Info("git is suspended after consecutive failures, change is not stored", "subpath", c.subpath)
+		return fmt.Errorf("git is suspended after consecutive failures")
+	}
+
+	l.gitMu.Lock()
+	err := l.syncGitWithTimeout(ctx, c)
+	l.gitMu.Unlock()
+
+	l.failures.mu.Lock()
+	defer l.failures.mu.Unlock()
+
+	if err == nil {
+		l.failures.consecutive = 0
+		metrics.ConsecutiveGitFailures.Set(0)
+		return nil
+	}
+
+	l.Logger.Error(err, "failed to sync git")
+	l.failures.consecutive++
+	metrics.ConsecutiveGitFailures.Set(float64(l.failures.consecutive))
+
+	if l.FailureThreshold <= 0 || l.failures.consecutive < l.FailureThreshold {
+		return err
+	}
+
+	metrics.PersistentGitFailures.Inc()
+	l.emitFailureEvent(ctx, l.failures.consecutive, err)
+	if l.SuspendOnFailure > 0 {
+		l.failures.suspendedUntil = time.Now().Add(l.SuspendOnFailure)
+		l.Logger.Info("suspending git after consecutive failures", "failures", l.failures.consecutive, "until", l.failures.suspendedUntil)
+	}
+	l.failures.consecutive = 0
+
+	return err
+}
+
+// emitFailureEvent records a warning event on the pod of the tracer
+func (l *ListenerWebhook) emitFailureEvent(ctx context.Context, failures int, err error) {
+	if l.PodName == "" {
+		return
+	}
+
+	l.emitEvent(ctx, corev1.ObjectReference{
+		APIVersion: "v1",
+		Kind:       "Pod",
+		Name:       l.PodName,
+		Namespace:  l.PodNamespace,
+	}, persistentFailureReason, fmt.Sprintf("%d consecutive git failures, last error: %s", failures, err))
+}
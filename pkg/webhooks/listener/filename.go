@@ -0,0 +1,36 @@
+package listener
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// escapeName makes an object name safe to be used as a path segment. Bytes other than ascii letters, digits,
+// '-', '_' and '.' are percent encoded so the name can be restored with unescapeName and distinct names never
+// map to the same path. The raw name is kept in the stored file as well.
+func escapeName(name string) string {
+	if name == "." || name == ".." {
+		return strings.ReplaceAll(name, ".", "%2E")
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(name); i++ {
+		c := name[i]
+		if c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '-' || c == '_' || c == '.' {
+			b.WriteByte(c)
+			continue
+		}
+		fmt.Fprintf(&b, "%%%02X", c)
+	}
+
+	return b.String()
+}
+
+func unescapeName(segment string) string {
+	name, err := url.PathUnescape(segment)
+	if err != nil {
+		return segment
+	}
+	return name
+}
@@ -0,0 +1,70 @@
+package listener
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/reborn1867/k8s-resource-tracer/pkg/git"
+)
+
+func TestEscapeName(t *testing.T) {
+	cases := []struct {
+		name string
+		want string
+	}{
+		{name: "app-1.v2_x", want: "app-1.v2_x"},
+		{name: "team/app", want: "team%2Fapp"},
+		{name: "system:controller:cron", want: "system%3Acontroller%3Acron"},
+		{name: "café", want: "caf%C3%A9"},
+		{name: "日本", want: "%E6%97%A5%E6%9C%AC"},
+		{name: "a%2Fb", want: "a%252Fb"},
+		{name: `back\slash`, want: "back%5Cslash"},
+		{name: ".", want: "%2E"},
+		{name: "..", want: "%2E%2E"},
+	}
+
+	seen := map[string]string{}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got := escapeName(c.name)
+			if got != c.want {
+				t.Errorf("expected %q, got %q", c.want, got)
+			}
+			if strings.ContainsAny(got, `/\:`) {
+				t.Errorf("expected a single safe path segment, got %q", got)
+			}
+			if back := unescapeName(got); back != c.name {
+				t.Errorf("expected %q to be restored, got %q", c.name, back)
+			}
+			if other, ok := seen[got]; ok {
+				t.Errorf("expected %q and %q to map to distinct paths", other, c.name)
+			}
+			seen[got] = c.name
+		})
+	}
+}
+
+func TestStoragePathRoundTrip(t *testing.T) {
+	l := newTestListener()
+	for _, name := range []string{"team/app", "system:controller:cron", "café", ".."} {
+		obj := map[string]interface{}{
+			"apiVersion": "apps/v1",
+			"kind":       "Deployment",
+			"metadata":   map[string]interface{}{"name": name, "namespace": "default"},
+		}
+		path := l.storagePath(context.Background(), obj, buildGVK(obj))
+		if dir, file := filepath.Split(path); dir != filepath.Join("default", "apps-v1.Deployment")+"/" || file == "" {
+			t.Errorf("expected %q to be stored in the directory of its gvk, got %s", name, path)
+		}
+
+		o, ok := l.parseStoragePath(git.FileChange{Path: path})
+		if !ok {
+			t.Fatalf("expected %s to be parsed", path)
+		}
+		if o.Name != name || o.Namespace != "default" || o.GVK != "apps-v1.Deployment" {
+			t.Errorf("expected %q in default, got %q in %q", name, o.Name, o.Namespace)
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package listener
+
+import (
+	"encoding/json"
+
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+// traces reports whether the request passes the namespace, kind and label filters
+func (l *ListenerWebhook) traces(r admission.Request) bool {
+	gvk := schema.GroupVersionKind{Group: r.Kind.Group, Version: r.Kind.Version, Kind: r.Kind.Kind}
+	return l.tracesNamespace(r.Namespace) && l.tracesKind(gvk) && l.tracesLabels(r)
+}
+
+// tracesNamespace reports whether objects in namespace are traced, ExcludeNamespaces wins over IncludeNamespaces
+// and all namespaces are traced if no include is configured. Cluster scoped objects are only filtered by exclude.
+func (l *ListenerWebhook) tracesNamespace(namespace string) bool {
+	for _, n := range l.ExcludeNamespaces {
+		if n == namespace {
+			return false
+		}
+	}
+	if len(l.IncludeNamespaces) == 0 || namespace == "" {
+		return true
+	}
+	for _, n := range l.IncludeNamespaces {
+		if n == namespace {
+			return true
+		}
+	}
+	return false
+}
+
+// tracesKind reports whether objects of gvk are traced, all kinds are if no ResourceSelectors are configured
+func (l *ListenerWebhook) tracesKind(gvk schema.GroupVersionKind) bool {
+	if len(l.ResourceSelectors) == 0 {
+		return true
+	}
+	for _, s := range l.ResourceSelectors {
+		if s == gvk {
+			return true
+		}
+	}
+	return false
+}
+
+// tracesLabels reports whether the labels of the new object, or of the old one on DELETE, match ObjectSelector,
+// all objects are traced if it's not configured
+func (l *ListenerWebhook) tracesLabels(r admission.Request) bool {
+	if l.ObjectSelector == nil || l.ObjectSelector.Empty() {
+		return true
+	}
+
+	raw := r.Object.Raw
+	if len(raw) == 0 {
+		raw = r.OldObject.Raw
+	}
+
+	obj := struct {
+		Metadata struct {
+			Labels map[string]string `json:"labels"`
+		} `json:"metadata"`
+	}{}
+	if err := json.Unmarshal(raw, &obj); err != nil {
+		// the object is traced so that handle reports it
+		return true
+	}
+
+	return l.ObjectSelector.Matches(labels.Set(obj.Metadata.Labels))
+}
@@ -0,0 +1,207 @@
+package listener
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"gomodules.xyz/jsonpatch/v2"
+	admissionv1 "k8s.io/api/admission/v1"
+	utilerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/workqueue"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+// FinalStateFinalizer holds back the deletion of traced objects until their final state has been recorded
+const FinalStateFinalizer = "k8s-resource-tracer/final-state"
+
+const (
+	releaseInterval = time.Second
+	releaseMaxDelay = 5 * time.Minute
+)
+
+// withFinalizer adds the final state finalizer to objects which are not being deleted yet, this relies on
+// the tracer being registered as a mutating webhook as validating webhooks can't patch objects
+func (l *ListenerWebhook) withFinalizer(r admission.Request, resp admission.Response) admission.Response {
+	if r.Operation != admissionv1.Create && r.Operation != admissionv1.Update {
+		return resp
+	}
+
+	obj := &unstructured.Unstructured{}
+	if err := obj.UnmarshalJSON(r.Object.Raw); err != nil {
+		l.Logger.Error(err, "failed to read object for finalizer")
+		return resp
+	}
+
+	if obj.GetDeletionTimestamp() != nil {
+		// e.g. deleted while the tracer was down, the release is queued again by any update of the object
+		if controllerutil.ContainsFinalizer(obj, FinalStateFinalizer) {
+			l.releaseQueue().Add(releaseKey{gvk: obj.GroupVersionKind(), key: client.ObjectKeyFromObject(obj)})
+		}
+		return resp
+	}
+	if controllerutil.ContainsFinalizer(obj, FinalStateFinalizer) {
+		return resp
+	}
+
+	op := jsonpatch.NewOperation("add", "/metadata/finalizers/-", FinalStateFinalizer)
+	if len(obj.GetFinalizers()) == 0 {
+		op = jsonpatch.NewOperation("add", "/metadata/finalizers", []string{FinalStateFinalizer})
+	}
+	resp.Patches = append(resp.Patches, op)
+
+	return resp
+}
+
+// releaseKey is an object whose final state finalizer is waiting to be removed
+type releaseKey struct {
+	gvk schema.GroupVersionKind
+	key types.NamespacedName
+}
+
+// holdsFinalizer reports whether the object deleted by r still carries the final state finalizer, its deletion is
+// recorded once the finalizer is removed
+func holdsFinalizer(r admission.Request) bool {
+	if r.DryRun != nil && *r.DryRun {
+		return false
+	}
+
+	obj := &unstructured.Unstructured{}
+	if err := obj.UnmarshalJSON(r.OldObject.Raw); err != nil {
+		return false
+	}
+	return controllerutil.ContainsFinalizer(obj, FinalStateFinalizer)
+}
+
+// finalRemoval returns the deletion of the object updated by r when the update removes the final state finalizer
+// from the terminating object, its final state is the one of the update
+func finalRemoval(r admission.Request) (admission.Request, bool) {
+	if r.Operation != admissionv1.Update || (r.DryRun != nil && *r.DryRun) {
+		return r, false
+	}
+
+	obj, oldObj := &unstructured.Unstructured{}, &unstructured.Unstructured{}
+	if err := obj.UnmarshalJSON(r.Object.Raw); err != nil {
+		return r, false
+	}
+	if err := oldObj.UnmarshalJSON(r.OldObject.Raw); err != nil {
+		return r, false
+	}
+	if obj.GetDeletionTimestamp() == nil || controllerutil.ContainsFinalizer(obj, FinalStateFinalizer) || !controllerutil.ContainsFinalizer(oldObj, FinalStateFinalizer) {
+		return r, false
+	}
+
+	removal := r
+	removal.Operation = admissionv1.Delete
+	removal.OldObject = r.Object
+	removal.Object = runtime.RawExtension{}
+	return removal, true
+}
+
+func (l *ListenerWebhook) releaseQueue() workqueue.RateLimitingInterface {
+	l.releaseOnce.Do(func() {
+		l.releases = workqueue.NewRateLimitingQueue(workqueue.NewItemExponentialFailureRateLimiter(releaseInterval, releaseMaxDelay))
+	})
+	return l.releases
+}
+
+// queueRelease queues the removal of the final state finalizer of the object in raw if it carries it
+func (l *ListenerWebhook) queueRelease(raw []byte) {
+	obj := &unstructured.Unstructured{}
+	if err := obj.UnmarshalJSON(raw); err != nil {
+		l.Logger.Error(err, "failed to read object for finalizer")
+		return
+	}
+
+	if !controllerutil.ContainsFinalizer(obj, FinalStateFinalizer) {
+		return
+	}
+	l.releaseQueue().Add(releaseKey{gvk: obj.GroupVersionKind(), key: client.ObjectKeyFromObject(obj)})
+}
+
+// QueueTerminating queues the objects of the given kinds which are being deleted and still carry the final state
+// finalizer, e.g. because the tracer restarted before releasing them
+func (l *ListenerWebhook) QueueTerminating(ctx context.Context, gvks []schema.GroupVersionKind) error {
+	for _, gvk := range gvks {
+		list := &unstructured.UnstructuredList{}
+		list.SetGroupVersionKind(gvk.GroupVersion().WithKind(gvk.Kind + "List"))
+		if err := l.Client.ListAllPages(ctx, list); err != nil {
+			return fmt.Errorf("failed to list %s: %s", gvk.String(), err)
+		}
+
+		for i := range list.Items {
+			obj := &list.Items[i]
+			if obj.GetDeletionTimestamp() != nil && controllerutil.ContainsFinalizer(obj, FinalStateFinalizer) {
+				l.releaseQueue().Add(releaseKey{gvk: gvk, key: client.ObjectKeyFromObject(obj)})
+			}
+		}
+	}
+
+	return nil
+}
+
+// RunFinalizerRelease removes the final state finalizer of the queued objects until ctx is done. Objects whose
+// deletion timestamp isn't set yet and failed removals are retried with backoff, nothing is given up.
+func (l *ListenerWebhook) RunFinalizerRelease(ctx context.Context) {
+	queue := l.releaseQueue()
+	go func() {
+		<-ctx.Done()
+		queue.ShutDown()
+	}()
+
+	for {
+		item, shutdown := queue.Get()
+		if shutdown {
+			return
+		}
+
+		k := item.(releaseKey)
+		done, err := l.releaseFinalizer(ctx, k)
+		if err != nil {
+			l.Logger.Error(err, "failed to remove finalizer, retrying", "finalizer", FinalStateFinalizer, "gvk", k.gvk.String(), "object", k.key.String())
+		}
+		if done {
+			queue.Forget(item)
+		} else {
+			queue.AddRateLimited(item)
+		}
+		queue.Done(item)
+	}
+}
+
+// releaseFinalizer removes the final state finalizer once the deletion of the object has started, it reports
+// whether the object is released. The removal is an UPDATE carrying the full object with its deletion timestamp,
+// which is traced as the final state and followed by the deletion.
+func (l *ListenerWebhook) releaseFinalizer(ctx context.Context, k releaseKey) (bool, error) {
+	current := &unstructured.Unstructured{}
+	current.SetGroupVersionKind(k.gvk)
+	if err := l.Client.Get(ctx, k.key, current); err != nil {
+		if utilerrors.IsNotFound(err) {
+			return true, nil
+		}
+		return false, err
+	}
+
+	if !controllerutil.ContainsFinalizer(current, FinalStateFinalizer) {
+		return true, nil
+	}
+	// the deletion timestamp is only set after the DELETE request has been admitted
+	if current.GetDeletionTimestamp() == nil {
+		return false, nil
+	}
+
+	if _, err := l.Client.GetAndPatch(ctx, current, func() error {
+		controllerutil.RemoveFinalizer(current, FinalStateFinalizer)
+		return nil
+	}); err != nil && !utilerrors.IsNotFound(err) {
+		return false, err
+	}
+
+	return true, nil
+}
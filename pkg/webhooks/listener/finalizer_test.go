@@ -0,0 +1,72 @@
+package listener
+
+import (
+	"encoding/json"
+	"testing"
+
+	admissionv1 "k8s.io/api/admission/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+func rawObject(t *testing.T, deleting bool, finalizers ...string) runtime.RawExtension {
+	t.Helper()
+	metadata := map[string]interface{}{"name": "cm", "namespace": "default"}
+	if deleting {
+		metadata["deletionTimestamp"] = "2024-01-01T00:00:00Z"
+	}
+	if len(finalizers) > 0 {
+		metadata["finalizers"] = finalizers
+	}
+	raw, err := json.Marshal(map[string]interface{}{"apiVersion": "v1", "kind": "ConfigMap", "metadata": metadata})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return runtime.RawExtension{Raw: raw}
+}
+
+func TestFinalRemoval(t *testing.T) {
+	dryRun := true
+	cases := []struct {
+		name   string
+		old    runtime.RawExtension
+		new    runtime.RawExtension
+		dryRun *bool
+		want   bool
+	}{
+		{name: "finalizer removed from terminating object", old: rawObject(t, true, FinalStateFinalizer), new: rawObject(t, true), want: true},
+		{name: "other finalizers left", old: rawObject(t, true, FinalStateFinalizer, "other"), new: rawObject(t, true, "other"), want: true},
+		{name: "finalizer kept", old: rawObject(t, true, FinalStateFinalizer), new: rawObject(t, true, FinalStateFinalizer)},
+		{name: "not terminating", old: rawObject(t, false, FinalStateFinalizer), new: rawObject(t, false)},
+		{name: "never held the finalizer", old: rawObject(t, true, "other"), new: rawObject(t, true)},
+		{name: "dry run", old: rawObject(t, true, FinalStateFinalizer), new: rawObject(t, true), dryRun: &dryRun},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			r := admission.Request{AdmissionRequest: admissionv1.AdmissionRequest{Operation: admissionv1.Update, Object: c.new, OldObject: c.old, DryRun: c.dryRun}}
+			removal, ok := finalRemoval(r)
+			if ok != c.want {
+				t.Fatalf("expected %v, got %v", c.want, ok)
+			}
+			if !ok {
+				return
+			}
+			if removal.Operation != admissionv1.Delete || len(removal.Object.Raw) != 0 || string(removal.OldObject.Raw) != string(c.new.Raw) {
+				t.Errorf("expected a deletion of the updated object, got %+v", removal.AdmissionRequest)
+			}
+		})
+	}
+}
+
+func TestHoldsFinalizer(t *testing.T) {
+	r := admission.Request{AdmissionRequest: admissionv1.AdmissionRequest{Operation: admissionv1.Delete, OldObject: rawObject(t, false, FinalStateFinalizer)}}
+	if !holdsFinalizer(r) {
+		t.Error("expected the finalizer to be held")
+	}
+
+	r.OldObject = rawObject(t, false, "other")
+	if holdsFinalizer(r) {
+		t.Error("expected deletion without the finalizer to be recorded at once")
+	}
+}
@@ -0,0 +1,64 @@
+package listener
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"gopkg.in/yaml.v2"
+)
+
+// Formats of the files objects are committed in
+const (
+	CommitFormatYAML = "yaml"
+	CommitFormatJSON = "json"
+)
+
+// ValidateCommitFormat returns an error for unknown commit formats, empty means CommitFormatYAML
+func ValidateCommitFormat(format string) error {
+	switch format {
+	case "", CommitFormatYAML, CommitFormatJSON:
+		return nil
+	}
+	return fmt.Errorf("unknown commit format %s, expected %s or %s", format, CommitFormatYAML, CommitFormatJSON)
+}
+
+// fileExtension is the extension of the files objects are stored in
+func (l *ListenerWebhook) fileExtension() string {
+	if l.CommitFormat == CommitFormatJSON {
+		return ".json"
+	}
+	return ".yaml"
+}
+
+// marshalObject serializes obj in the commit format, json is indented to keep diffs of the history readable
+func (l *ListenerWebhook) marshalObject(obj map[string]interface{}) ([]byte, error) {
+	if l.CommitFormat != CommitFormatJSON {
+		return yaml.Marshal(obj)
+	}
+
+	data, err := json.MarshalIndent(obj, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
+
+// prepareStored drops the metadata which isn't stored and redacts RedactPaths of obj in place
+func (l *ListenerWebhook) prepareStored(obj map[string]interface{}) {
+	l.sanitizeMetadata(obj)
+	l.redactPaths(obj)
+}
+
+// encodeStored serializes obj prepared by prepareStored with its header, errors are logged
+func (l *ListenerWebhook) encodeStored(obj map[string]interface{}, gvk, name, namespace string) []byte {
+	output, err := l.marshalObject(obj)
+	if err != nil {
+		l.Logger.Error(err, "failed to serialize object", "format", l.fileExtension())
+	}
+	if withHeader, err := l.withHeader(output, gvk, name, namespace); err != nil {
+		l.Logger.Error(err, "failed to render header")
+	} else {
+		output = withHeader
+	}
+	return output
+}
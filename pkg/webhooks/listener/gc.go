@@ -0,0 +1,41 @@
+package listener
+
+import (
+	"context"
+	"time"
+
+	"github.com/reborn1867/k8s-resource-tracer/pkg/git"
+)
+
+// RunGC compacts the repository on start and every interval until ctx is done, holding the git lock so that
+// no commit is written meanwhile. Changes staged for the next batch are kept.
+func (l *ListenerWebhook) RunGC(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if l.writesGit() {
+			l.gc()
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (l *ListenerWebhook) gc() {
+	l.gitMu.Lock()
+	defer l.gitMu.Unlock()
+
+	start := time.Now()
+	before, after, err := git.GC(l.GitPath)
+	if err != nil {
+		l.Logger.Error(err, "failed to compact git repository", "path", l.GitPath)
+		return
+	}
+
+	l.Logger.Info("compacted git repository", "path", l.GitPath, "sizeBefore", before, "sizeAfter", after, "duration", time.Since(start))
+}
@@ -0,0 +1,40 @@
+package listener
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+)
+
+const contentHashTrailer = "Content-Hash"
+
+// contentHash returns a stable hash of obj. encoding/json sorts map keys, so the json form is canonical
+// regardless of the order the fields were received in.
+func contentHash(obj map[string]interface{}) (string, error) {
+	data, err := json.Marshal(obj)
+	if err != nil {
+		return "", err
+	}
+
+	sum := sha256.Sum256(data)
+	return "sha256:" + hex.EncodeToString(sum[:]), nil
+}
+
+// sameContent reports whether old and new hash the same once the ignored fields are dropped, created and deleted
+// objects never do
+func (l *ListenerWebhook) sameContent(old, new map[string]interface{}) (bool, error) {
+	if len(old) == 0 || len(new) == 0 {
+		return false, nil
+	}
+
+	oldHash, err := contentHash(l.withoutIgnoredFields(old))
+	if err != nil {
+		return false, err
+	}
+	newHash, err := contentHash(l.withoutIgnoredFields(new))
+	if err != nil {
+		return false, err
+	}
+
+	return oldHash == newHash, nil
+}
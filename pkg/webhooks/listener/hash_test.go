@@ -0,0 +1,90 @@
+package listener
+
+import (
+	"context"
+	"encoding/json"
+	"regexp"
+	"testing"
+
+	admissionv1 "k8s.io/api/admission/v1"
+)
+
+func TestContentHashStableAcrossMarshals(t *testing.T) {
+	docs := []string{
+		`{"apiVersion":"v1","kind":"ConfigMap","metadata":{"name":"cm","labels":{"a":"1","b":"2"}},"data":{"x":"1","y":"2"}}`,
+		`{"data":{"y":"2","x":"1"},"metadata":{"labels":{"b":"2","a":"1"},"name":"cm"},"kind":"ConfigMap","apiVersion":"v1"}`,
+		"{\n  \"kind\": \"ConfigMap\",\n  \"apiVersion\": \"v1\",\n  \"data\": {\"x\": \"1\", \"y\": \"2\"},\n  \"metadata\": {\"name\": \"cm\", \"labels\": {\"a\": \"1\", \"b\": \"2\"}}\n}",
+	}
+
+	var want string
+	for i, doc := range docs {
+		obj := map[string]interface{}{}
+		if err := json.Unmarshal([]byte(doc), &obj); err != nil {
+			t.Fatal(err)
+		}
+		hash, err := contentHash(obj)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// marshalling and unmarshalling again must not change the hash
+		data, err := json.Marshal(obj)
+		if err != nil {
+			t.Fatal(err)
+		}
+		again := map[string]interface{}{}
+		if err := json.Unmarshal(data, &again); err != nil {
+			t.Fatal(err)
+		}
+		rehash, err := contentHash(again)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if rehash != hash {
+			t.Errorf("expected the hash to survive a re-marshal, got %s and %s", hash, rehash)
+		}
+
+		if i == 0 {
+			want = hash
+		} else if hash != want {
+			t.Errorf("expected document %d to hash to %s, got %s", i, want, hash)
+		}
+	}
+
+	changed := map[string]interface{}{"apiVersion": "v1", "kind": "ConfigMap", "metadata": map[string]interface{}{"name": "cm"}, "data": map[string]interface{}{"x": "2"}}
+	if hash, _ := contentHash(changed); hash == want {
+		t.Error("expected a changed object to hash differently")
+	}
+}
+
+func TestContentHashTrailer(t *testing.T) {
+	// server managed fields are not stored, objects differing only in them must carry the same hash
+	trailers := map[string]bool{}
+	for _, rv := range []string{"1", "2"} {
+		l, repo, _ := gitListener(t)
+		old := deployment(map[string]interface{}{"replicas": int64(1)})
+		obj := deployment(map[string]interface{}{"replicas": int64(2)})
+		obj["metadata"].(map[string]interface{})["resourceVersion"] = rv
+		if resp := l.handle(context.Background(), request(t, admissionv1.Update, old, obj)); !resp.Allowed {
+			t.Fatalf("expected request to be allowed, got %v", resp.Result)
+		}
+
+		head, err := repo.Head()
+		if err != nil {
+			t.Fatal(err)
+		}
+		commit, err := repo.CommitObject(head.Hash())
+		if err != nil {
+			t.Fatal(err)
+		}
+		m := regexp.MustCompile(`(?m)^` + contentHashTrailer + `: (sha256:[0-9a-f]{64})$`).FindStringSubmatch(commit.Message)
+		if m == nil {
+			t.Fatalf("expected a %s trailer, got %q", contentHashTrailer, commit.Message)
+		}
+		trailers[m[1]] = true
+	}
+
+	if len(trailers) != 1 {
+		t.Errorf("expected the same hash for the same stored content, got %v", trailers)
+	}
+}
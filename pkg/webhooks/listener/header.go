@@ -0,0 +1,58 @@
+package listener
+
+import (
+	"bytes"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// DefaultHeaderTemplate is the banner prepended to stored files
+const DefaultHeaderTemplate = `Generated by k8s-resource-tracer, do not edit.
+gvk: {{ .GVK }}
+object: {{ if .Namespace }}{{ .Namespace }}/{{ end }}{{ .Name }}
+traced at: {{ .Timestamp }}`
+
+// headerData holds the fields available in header templates
+type headerData struct {
+	GVK       string
+	Name      string
+	Namespace string
+	Timestamp string
+}
+
+// ParseHeaderTemplate parses a header template, an empty template disables the header
+func ParseHeaderTemplate(text string) (*template.Template, error) {
+	if text == "" {
+		return nil, nil
+	}
+	return template.New("header").Option("missingkey=error").Parse(text)
+}
+
+// withHeader prepends the rendered header to data as a yaml comment block, every line is commented so the
+// file still parses to the same object. json has no comments, json files are left as they are.
+func (l *ListenerWebhook) withHeader(data []byte, gvk, name, namespace string) ([]byte, error) {
+	if l.HeaderTemplate == nil || l.CommitFormat == CommitFormatJSON {
+		return data, nil
+	}
+
+	out := bytes.Buffer{}
+	if err := l.HeaderTemplate.Execute(&out, headerData{
+		GVK:       gvk,
+		Name:      name,
+		Namespace: namespace,
+		Timestamp: time.Now().UTC().Format(time.RFC3339),
+	}); err != nil {
+		return nil, err
+	}
+
+	header := bytes.Buffer{}
+	for _, line := range strings.Split(strings.TrimRight(out.String(), "\n"), "\n") {
+		if !strings.HasPrefix(line, "#") {
+			line = strings.TrimRight("# "+line, " ")
+		}
+		header.WriteString(line + "\n")
+	}
+
+	return append(header.Bytes(), data...), nil
+}
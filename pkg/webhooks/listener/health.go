@@ -0,0 +1,65 @@
+package listener
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/reborn1867/k8s-resource-tracer/pkg/git"
+)
+
+const defaultRemoteCheckTimeout = 10 * time.Second
+
+var errRemoteNotChecked = errors.New("git remote not checked yet")
+
+// RunRemoteCheck lists the references of the remote on start and every interval until ctx is done,
+// CheckRemote reports the result of the last attempt
+func (l *ListenerWebhook) RunRemoteCheck(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		l.checkRemote(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (l *ListenerWebhook) checkRemote(ctx context.Context) {
+	timeout := l.GitOpTimeout
+	if timeout <= 0 {
+		timeout = defaultRemoteCheckTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	err := git.ListRemote(ctx, l.GitURL, l.GitAuth)
+	if err != nil {
+		err = fmt.Errorf("failed to reach git remote, url: %s, err: %s", l.GitURL, err)
+		l.Logger.Error(err, "git remote check failed")
+	}
+
+	l.remoteMu.Lock()
+	defer l.remoteMu.Unlock()
+	if l.remoteErr != nil && err == nil {
+		l.Logger.Info("git remote reachable again", "url", l.GitURL)
+	}
+	l.remoteErr, l.remoteChecked = err, true
+}
+
+// CheckRemote is a readiness check failing while the git remote is unreachable
+func (l *ListenerWebhook) CheckRemote(_ *http.Request) error {
+	l.remoteMu.Lock()
+	defer l.remoteMu.Unlock()
+
+	if !l.remoteChecked {
+		return errRemoteNotChecked
+	}
+	return l.remoteErr
+}
@@ -0,0 +1,98 @@
+package listener
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	gg "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
+	"github.com/go-logr/logr"
+	admissionv1 "k8s.io/api/admission/v1"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	"github.com/reborn1867/k8s-resource-tracer/pkg/backend"
+)
+
+// newTestListener returns a listener which logs changes without git
+func newTestListener() *ListenerWebhook {
+	return &ListenerWebhook{Logger: logr.Discard(), StoreBinaryData: true}
+}
+
+// gitListener returns a listener committing to a new repository which pushes to a new bare repository, the remote
+func gitListener(t *testing.T) (*ListenerWebhook, *gg.Repository, *gg.Repository) {
+	t.Helper()
+	remotePath := t.TempDir()
+	remote, err := gg.PlainInit(remotePath, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	l := newTestListener()
+	l.EnableGitReview = true
+	l.StoredMetadataFields = DefaultStoredMetadataFields
+	l.GitPath = t.TempDir()
+	repo, err := gg.PlainInit(l.GitPath, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := repo.CreateRemote(&config.RemoteConfig{Name: "origin", URLs: []string{remotePath}}); err != nil {
+		t.Fatal(err)
+	}
+	return l, repo, remote
+}
+
+// request returns an admission request of op changing old to obj, either may be nil
+func request(t *testing.T, op admissionv1.Operation, old, obj map[string]interface{}) admission.Request {
+	t.Helper()
+	r := admission.Request{AdmissionRequest: admissionv1.AdmissionRequest{
+		UID:       "uid",
+		Kind:      metav1.GroupVersionKind{Group: "apps", Version: "v1", Kind: "Deployment"},
+		Resource:  metav1.GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments"},
+		Name:      "app",
+		Namespace: "default",
+		Operation: op,
+		UserInfo:  authenticationv1.UserInfo{Username: "alice"},
+	}}
+	for raw, o := range map[*runtime.RawExtension]map[string]interface{}{&r.Object: obj, &r.OldObject: old} {
+		if o == nil {
+			continue
+		}
+		data, err := json.Marshal(o)
+		if err != nil {
+			t.Fatal(err)
+		}
+		raw.Raw = data
+	}
+	return r
+}
+
+// deployment returns a deployment in the default namespace with spec
+func deployment(spec map[string]interface{}) map[string]interface{} {
+	return map[string]interface{}{
+		"apiVersion": "apps/v1",
+		"kind":       "Deployment",
+		"metadata":   map[string]interface{}{"name": "app", "namespace": "default", "resourceVersion": "1"},
+		"spec":       spec,
+	}
+}
+
+// changes decodes the changes written to out by a stdout backend
+func changes(t *testing.T, out *bytes.Buffer) []backend.Change {
+	t.Helper()
+	var cs []backend.Change
+	scanner := bufio.NewScanner(bytes.NewReader(out.Bytes()))
+	scanner.Buffer(nil, 1<<20)
+	for scanner.Scan() {
+		var c backend.Change
+		if err := json.Unmarshal(scanner.Bytes(), &c); err != nil {
+			t.Fatal(err)
+		}
+		cs = append(cs, c)
+	}
+	return cs
+}
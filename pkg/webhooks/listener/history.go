@@ -0,0 +1,115 @@
+package listener
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/reborn1867/k8s-resource-tracer/pkg/git"
+)
+
+// HistoryDir is the directory next to the stored objects keeping their snapshots with KeepHistory
+const HistoryDir = "history"
+
+// DefaultHistoryLimit is the number of snapshots kept per object
+const DefaultHistoryLimit = 10
+
+// historyPath returns the path of the snapshot of the object stored at subpath in its version resourceVersion
+func (l *ListenerWebhook) historyPath(subpath, resourceVersion string) string {
+	name := strings.TrimSuffix(filepath.Base(subpath), l.fileExtension())
+	return filepath.Join(filepath.Dir(subpath), HistoryDir, name+"."+escapeName(resourceVersion)+l.fileExtension())
+}
+
+// withHistory adds the snapshot of old, the object before the change, to c. Admission requests carry the
+// resourceVersion the change is based on, the version after the change is only known once it's stored, so every
+// snapshot is a state the object had before a change and the latest state is the stored object itself.
+func (l *ListenerWebhook) withHistory(c change, old map[string]interface{}, gvk string) change {
+	metadata, _ := old["metadata"].(map[string]interface{})
+	resourceVersion, _ := metadata["resourceVersion"].(string)
+	if resourceVersion == "" {
+		return c
+	}
+	name, _ := metadata["name"].(string)
+	namespace, _ := metadata["namespace"].(string)
+
+	l.prepareStored(old)
+	c.history = l.historyPath(c.subpath, resourceVersion)
+	c.historyData = l.encodeStored(old, gvk, name, namespace)
+	return c
+}
+
+// stageHistory writes the snapshot of c and removes the oldest snapshots of the object above HistoryLimit
+func (l *ListenerWebhook) stageHistory(c change) error {
+	if c.history == "" {
+		return nil
+	}
+
+	target := filepath.Join(l.GitPath, c.history)
+	if _, err := os.Stat(target); err == nil {
+		// the version was stored by a previous change, e.g. a retried request
+		return nil
+	}
+	if err := git.StageChange(l.GitPath, c.history, c.historyData, l.Logger); err != nil {
+		return fmt.Errorf("failed to stage snapshot: %s", err)
+	}
+
+	if l.HistoryLimit <= 0 {
+		return nil
+	}
+
+	snapshots, err := l.snapshots(c.subpath)
+	if err != nil {
+		return err
+	}
+	for len(snapshots) > l.HistoryLimit {
+		if err := git.StageRemoval(l.GitPath, snapshots[0], l.Logger); err != nil {
+			return fmt.Errorf("failed to stage removal of snapshot: %s", err)
+		}
+		snapshots = snapshots[1:]
+	}
+
+	return nil
+}
+
+// snapshots lists the snapshots of the object stored at subpath relative to the repository, oldest first
+func (l *ListenerWebhook) snapshots(subpath string) ([]string, error) {
+	dir := filepath.Join(filepath.Dir(subpath), HistoryDir)
+	entries, err := os.ReadDir(filepath.Join(l.GitPath, dir))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read history, path: %s, err: %s", dir, err)
+	}
+
+	prefix := strings.TrimSuffix(filepath.Base(subpath), l.fileExtension()) + "."
+	versions := []string{}
+	for _, e := range entries {
+		version, ok := strings.CutPrefix(e.Name(), prefix)
+		if !ok || e.IsDir() || !strings.HasSuffix(version, l.fileExtension()) {
+			continue
+		}
+		version = strings.TrimSuffix(version, l.fileExtension())
+		// names are allowed to contain dots, the snapshots of a.b are no snapshots of a
+		if version == "" || strings.Contains(version, ".") {
+			continue
+		}
+		versions = append(versions, version)
+	}
+
+	// resource versions are opaque but numbers in practice
+	sort.Slice(versions, func(i, j int) bool {
+		a, errA := strconv.ParseUint(versions[i], 10, 64)
+		b, errB := strconv.ParseUint(versions[j], 10, 64)
+		if errA != nil || errB != nil {
+			return versions[i] < versions[j]
+		}
+		return a < b
+	})
+
+	paths := make([]string, 0, len(versions))
+	for _, v := range versions {
+		paths = append(paths, filepath.Join(dir, prefix+v+l.fileExtension()))
+	}
+	return paths, nil
+}
@@ -0,0 +1,49 @@
+package listener
+
+// DefaultIgnoredFields are metadata fields bumped by the api server on every update, they are left out of diffs
+// and stored objects
+var DefaultIgnoredFields = []string{"resourceVersion", "generation", "managedFields", "creationTimestamp", "uid"}
+
+// ignores reports whether the metadata field is in IgnoreFields
+func (l *ListenerWebhook) ignores(field string) bool {
+	for _, f := range l.IgnoreFields {
+		if f == field {
+			return true
+		}
+	}
+	return false
+}
+
+// strips reports whether the metadata field is left out of diffs and storage, managedFields always is
+func (l *ListenerWebhook) strips(field string) bool {
+	return field == "managedFields" || l.ignores(field)
+}
+
+// stripIgnoredFields returns a copy of metadata without managedFields and the ignored fields, it is shared by
+// diffing and storage so that both leave out the same fields
+func (l *ListenerWebhook) stripIgnoredFields(metadata map[string]interface{}) map[string]interface{} {
+	stripped := make(map[string]interface{}, len(metadata))
+	for k, v := range metadata {
+		if !l.strips(k) {
+			stripped[k] = v
+		}
+	}
+	return stripped
+}
+
+// withoutIgnoredFields returns a shallow copy of obj with stripped metadata, obj itself is kept intact since the
+// field manager, drift detection and backends read them
+func (l *ListenerWebhook) withoutIgnoredFields(obj map[string]interface{}) map[string]interface{} {
+	metadata, ok := obj["metadata"].(map[string]interface{})
+	if !ok {
+		return obj
+	}
+
+	copied := make(map[string]interface{}, len(obj))
+	for k, v := range obj {
+		copied[k] = v
+	}
+	copied["metadata"] = l.stripIgnoredFields(metadata)
+
+	return copied
+}
@@ -0,0 +1,96 @@
+package listener
+
+import (
+	"context"
+	"fmt"
+
+	admissionv1 "k8s.io/api/admission/v1"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/dynamic/dynamicinformer"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/cache"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+// InformerActor is the user changes observed by informers are attributed to, informers don't know who made a change
+const InformerActor = "system:k8s-resource-tracer:informer"
+
+// StartInformers traces updates of the given kinds from watch events instead of admission requests, for resources
+// the webhook can't intercept, e.g. in managed control planes. The events are fed through the same pipeline as
+// admission requests.
+func (l *ListenerWebhook) StartInformers(ctx context.Context, cfg *rest.Config, mapper meta.RESTMapper, gvks []schema.GroupVersionKind) error {
+	dc, err := dynamic.NewForConfig(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to create dynamic client: %s", err)
+	}
+
+	factory := dynamicinformer.NewDynamicSharedInformerFactory(dc, 0)
+	for _, gvk := range gvks {
+		mapping, err := mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+		if err != nil {
+			return fmt.Errorf("failed to find resource of %s: %s", gvk.String(), err)
+		}
+
+		resource := mapping.Resource
+		if _, err := factory.ForResource(resource).Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+			UpdateFunc: func(oldObj, newObj interface{}) {
+				l.handleInformerUpdate(ctx, gvk, resource, oldObj, newObj)
+			},
+		}); err != nil {
+			return fmt.Errorf("failed to watch %s: %s", resource.String(), err)
+		}
+		l.Logger.Info("tracing resource with informer", "gvk", gvk.String(), "resource", resource.String())
+	}
+
+	factory.Start(ctx.Done())
+	return nil
+}
+
+func (l *ListenerWebhook) handleInformerUpdate(ctx context.Context, gvk schema.GroupVersionKind, resource schema.GroupVersionResource, oldObj, newObj interface{}) {
+	oldU, ok := oldObj.(*unstructured.Unstructured)
+	if !ok {
+		return
+	}
+	newU, ok := newObj.(*unstructured.Unstructured)
+	if !ok {
+		return
+	}
+
+	// resyncs deliver the same version twice
+	if oldU.GetResourceVersion() == newU.GetResourceVersion() {
+		return
+	}
+
+	oldRaw, err := oldU.MarshalJSON()
+	if err != nil {
+		l.Logger.Error(err, "failed to marshal old object of informer event")
+		return
+	}
+	newRaw, err := newU.MarshalJSON()
+	if err != nil {
+		l.Logger.Error(err, "failed to marshal object of informer event")
+		return
+	}
+
+	r := admission.Request{AdmissionRequest: admissionv1.AdmissionRequest{
+		UID:       types.UID(newU.GetUID() + types.UID("-"+newU.GetResourceVersion())),
+		Kind:      metav1.GroupVersionKind{Group: gvk.Group, Version: gvk.Version, Kind: gvk.Kind},
+		Resource:  metav1.GroupVersionResource{Group: resource.Group, Version: resource.Version, Resource: resource.Resource},
+		Name:      newU.GetName(),
+		Namespace: newU.GetNamespace(),
+		Operation: admissionv1.Update,
+		UserInfo:  authenticationv1.UserInfo{Username: InformerActor},
+		Object:    runtime.RawExtension{Raw: newRaw},
+		OldObject: runtime.RawExtension{Raw: oldRaw},
+	}}
+
+	// the same filters and limits as admission requests apply, there is no response to return
+	l.gate(ctx, r, l.handle)
+}
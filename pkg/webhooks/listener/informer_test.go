@@ -0,0 +1,97 @@
+package listener
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/reborn1867/k8s-resource-tracer/pkg/backend"
+)
+
+func informerObjects(resourceVersion string, replicas int64, labels map[string]interface{}) (*unstructured.Unstructured, *unstructured.Unstructured) {
+	old := &unstructured.Unstructured{Object: deployment(map[string]interface{}{"replicas": int64(1)})}
+	obj := &unstructured.Unstructured{Object: deployment(map[string]interface{}{"replicas": replicas})}
+	obj.SetResourceVersion(resourceVersion)
+	if labels != nil {
+		old.Object["metadata"].(map[string]interface{})["labels"] = labels
+		obj.Object["metadata"].(map[string]interface{})["labels"] = labels
+	}
+	return old, obj
+}
+
+func TestHandleInformerUpdateAppliesGate(t *testing.T) {
+	gvk := schema.GroupVersionKind{Group: "apps", Version: "v1", Kind: "Deployment"}
+	resource := schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments"}
+	selector, err := labels.Parse("tier=web")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		name      string
+		configure func(l *ListenerWebhook)
+		labels    map[string]interface{}
+		replicas  int64
+		want      bool
+	}{
+		{name: "traced", replicas: 2, want: true},
+		{name: "same version", replicas: 1},
+		{name: "kind not traced", replicas: 2, configure: func(l *ListenerWebhook) {
+			l.ResourceSelectors = []schema.GroupVersionKind{{Version: "v1", Kind: "ConfigMap"}}
+		}},
+		{name: "namespace excluded", replicas: 2, configure: func(l *ListenerWebhook) {
+			l.ExcludeNamespaces = []string{"default"}
+		}},
+		{name: "labels not selected", replicas: 2, labels: map[string]interface{}{"tier": "db"}, configure: func(l *ListenerWebhook) {
+			l.ObjectSelector = selector
+		}},
+		{name: "labels selected", replicas: 2, labels: map[string]interface{}{"tier": "web"}, want: true, configure: func(l *ListenerWebhook) {
+			l.ObjectSelector = selector
+		}},
+		{name: "oversized", replicas: 2, configure: func(l *ListenerWebhook) {
+			l.MaxObjectBytes = 10
+		}},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			out := &bytes.Buffer{}
+			l := newTestListener()
+			l.Backends = []backend.Backend{backend.NewStdoutBackend(out)}
+			if c.configure != nil {
+				c.configure(l)
+			}
+
+			resourceVersion := "2"
+			if c.replicas == 1 {
+				resourceVersion = "1"
+			}
+			old, obj := informerObjects(resourceVersion, c.replicas, c.labels)
+			l.handleInformerUpdate(context.Background(), gvk, resource, old, obj)
+
+			if traced := out.Len() > 0; traced != c.want {
+				t.Errorf("expected traced %v, got %v", c.want, traced)
+			}
+		})
+	}
+}
+
+type panickingBackend struct{}
+
+func (panickingBackend) Store(ctx context.Context, c backend.Change) error {
+	panic("store failed")
+}
+
+func TestHandleInformerUpdateRecoversPanic(t *testing.T) {
+	l := newTestListener()
+	l.Backends = []backend.Backend{panickingBackend{}}
+
+	old, obj := informerObjects("2", 2, nil)
+	// a panic would kill the informer goroutine and fail the test
+	l.handleInformerUpdate(context.Background(), schema.GroupVersionKind{Group: "apps", Version: "v1", Kind: "Deployment"},
+		schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments"}, old, obj)
+}
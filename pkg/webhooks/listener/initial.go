@@ -0,0 +1,85 @@
+package listener
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	admissionv1 "k8s.io/api/admission/v1"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+// InitialSyncActor is the user the objects captured by the initial sync are attributed to
+const InitialSyncActor = "system:k8s-resource-tracer:initial-sync"
+
+// InitialSync lists the existing objects of the given kinds and stores the ones not stored yet as if they were
+// created, so the repository starts from the current state of the cluster. Objects already stored are skipped,
+// which makes restarts cheap and keeps their history untouched.
+func (l *ListenerWebhook) InitialSync(ctx context.Context, gvks []schema.GroupVersionKind) error {
+	if l.Client == nil {
+		return fmt.Errorf("initial sync needs a kubernetes client")
+	}
+
+	for _, gvk := range gvks {
+		list := &unstructured.UnstructuredList{}
+		list.SetGroupVersionKind(gvk.GroupVersion().WithKind(gvk.Kind + "List"))
+		if err := l.Client.ListAllPages(ctx, list); err != nil {
+			return fmt.Errorf("failed to list %s: %s", gvk.String(), err)
+		}
+
+		synced, skipped := 0, 0
+		for i := range list.Items {
+			if l.syncExisting(ctx, gvk, &list.Items[i]) {
+				synced++
+			} else {
+				skipped++
+			}
+		}
+		l.Logger.Info("initial sync of resource done", "gvk", gvk.String(), "synced", synced, "skipped", skipped)
+	}
+
+	return nil
+}
+
+// syncExisting feeds obj through the admission pipeline as a creation, it reports whether obj was handled
+func (l *ListenerWebhook) syncExisting(ctx context.Context, gvk schema.GroupVersionKind, obj *unstructured.Unstructured) bool {
+	raw, err := obj.MarshalJSON()
+	if err != nil {
+		l.Logger.Error(err, "failed to marshal object of initial sync", "name", obj.GetName(), "namespace", obj.GetNamespace())
+		return false
+	}
+
+	r := admission.Request{AdmissionRequest: admissionv1.AdmissionRequest{
+		UID:       obj.GetUID(),
+		Kind:      metav1.GroupVersionKind{Group: gvk.Group, Version: gvk.Version, Kind: gvk.Kind},
+		Name:      obj.GetName(),
+		Namespace: obj.GetNamespace(),
+		Operation: admissionv1.Create,
+		UserInfo:  authenticationv1.UserInfo{Username: InitialSyncActor},
+		Object:    runtime.RawExtension{Raw: raw},
+	}}
+
+	// objects are synced one at a time, the concurrency limit of admission requests doesn't apply
+	if !l.traces(r) || l.oversized(r) {
+		return false
+	}
+
+	subpath := l.storagePath(ctx, obj.Object, buildGVK(obj.Object))
+	if _, err := os.Stat(filepath.Join(l.GitPath, subpath)); err == nil {
+		return false
+	}
+
+	// a panic skips the object instead of aborting the sync
+	func() {
+		var resp admission.Response
+		defer l.recoverPanic(&resp)
+		l.handle(ctx, r)
+	}()
+	return true
+}
@@ -0,0 +1,70 @@
+package listener
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/reborn1867/k8s-resource-tracer/pkg/backend"
+)
+
+func TestSyncExistingAppliesFilters(t *testing.T) {
+	gvk := schema.GroupVersionKind{Group: "apps", Version: "v1", Kind: "Deployment"}
+	selector, err := labels.Parse("tier=web")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		name      string
+		configure func(l *ListenerWebhook)
+		stored    bool
+		want      bool
+	}{
+		{name: "synced", want: true},
+		{name: "already stored", stored: true},
+		{name: "namespace excluded", configure: func(l *ListenerWebhook) { l.ExcludeNamespaces = []string{"default"} }},
+		{name: "kind not traced", configure: func(l *ListenerWebhook) {
+			l.ResourceSelectors = []schema.GroupVersionKind{{Version: "v1", Kind: "ConfigMap"}}
+		}},
+		{name: "labels not selected", configure: func(l *ListenerWebhook) { l.ObjectSelector = selector }},
+		{name: "oversized", configure: func(l *ListenerWebhook) { l.MaxObjectBytes = 10 }},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			out := &bytes.Buffer{}
+			l := newTestListener()
+			l.GitPath = t.TempDir()
+			l.Backends = []backend.Backend{backend.NewStdoutBackend(out)}
+			if c.configure != nil {
+				c.configure(l)
+			}
+
+			obj := &unstructured.Unstructured{Object: deployment(map[string]interface{}{"replicas": int64(1)})}
+			obj.SetLabels(map[string]string{"tier": "db"})
+			if c.stored {
+				target := filepath.Join(l.GitPath, l.storagePath(context.Background(), obj.Object, buildGVK(obj.Object)))
+				if err := os.MkdirAll(filepath.Dir(target), os.ModePerm); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(target, []byte("{}"), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			if got := l.syncExisting(context.Background(), gvk, obj); got != c.want {
+				t.Errorf("expected synced %v, got %v", c.want, got)
+			}
+			if traced := out.Len() > 0; traced != c.want {
+				t.Errorf("expected recorded %v, got %v", c.want, traced)
+			}
+		})
+	}
+}
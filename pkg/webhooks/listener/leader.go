@@ -0,0 +1,38 @@
+package listener
+
+import (
+	"context"
+	"errors"
+	"net/http"
+)
+
+var errNotLeader = errors.New("not the leader, admission requests are only traced by the leader")
+
+// StartLeading enables git writes of a replica which has been elected leader
+func (l *ListenerWebhook) StartLeading() {
+	l.leading.Store(true)
+}
+
+// writesGit reports whether this replica writes traced changes to git, with leader election only the leader does
+func (l *ListenerWebhook) writesGit() bool {
+	return l.EnableGitReview && (!l.LeaderElection || l.leading.Load())
+}
+
+// CheckLeader fails the readiness of followers, so that the service of the webhook only sends admission requests to
+// the leader, the only replica writing them to git
+func (l *ListenerWebhook) CheckLeader(_ *http.Request) error {
+	if l.LeaderElection && !l.leading.Load() {
+		return errNotLeader
+	}
+	return nil
+}
+
+// Shutdown waits for the git write in flight, then commits the changes left in the work tree and pushes the
+// commits which didn't reach the remote, e.g. because a push failed
+func (l *ListenerWebhook) Shutdown(ctx context.Context) error {
+	if !l.writesGit() || l.DryRun {
+		return nil
+	}
+
+	return l.FlushPending(ctx)
+}
@@ -3,164 +3,666 @@ package listener
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"path/filepath"
 	"strings"
+	"sync"
+	"sync/atomic"
+	"text/template"
+	"time"
 
 	"github.com/go-git/go-git/v5/plumbing/transport"
 	"github.com/go-logr/logr"
 	jd "github.com/josephburnett/jd/lib"
-	"gopkg.in/yaml.v2"
+	admissionv1 "k8s.io/api/admission/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/util/workqueue"
 	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
 
+	"github.com/reborn1867/k8s-resource-tracer/pkg/backend"
+	"github.com/reborn1867/k8s-resource-tracer/pkg/common"
+	"github.com/reborn1867/k8s-resource-tracer/pkg/deadletter"
 	"github.com/reborn1867/k8s-resource-tracer/pkg/git"
+	"github.com/reborn1867/k8s-resource-tracer/pkg/metrics"
+	"github.com/reborn1867/k8s-resource-tracer/pkg/review"
 )
 
+// RequiresApprovalAnnotation lets objects declare that their changes go through a pull request
+const RequiresApprovalAnnotation = "tracer.io/requires-approval"
+
 type ListenerWebhook struct {
 	Logger          logr.Logger
 	EnableGitReview bool
+	// DryRun computes and logs the changes git would get but never commits or pushes them
+	DryRun bool
+	// IgnoreStatusChanges drops status from both diff and storage
+	IgnoreStatusChanges bool
+	// CaptureFinalState adds a finalizer to traced objects so their final state is recorded before deletion
+	CaptureFinalState bool
+	// IncludeNamespaces limits tracing to these namespaces, ExcludeNamespaces are never traced and win over it
+	IncludeNamespaces []string
+	ExcludeNamespaces []string
+	// ResourceSelectors limits tracing to these kinds, all kinds are traced if empty
+	ResourceSelectors []schema.GroupVersionKind
+	// ObjectSelector limits tracing to objects whose labels match it, all objects are traced if nil
+	ObjectSelector labels.Selector
+	// MaxObjectBytes is the size above which objects are admitted without tracing, zero means no limit
+	MaxObjectBytes int
+	// SetKeys are lists diffed regardless of the order of their items
+	SetKeys []SetPath
+	// DiffOptions are jd metadata passed to every diff, e.g. to diff all lists as sets, they don't change rendering
+	DiffOptions []CustomRenderOption
+	// AllowPaths reduce objects to these paths before diffing and storage, apart from their identity
+	AllowPaths []Path
+	// RedactSecrets replaces the values of secrets by a hash before diffing and storage
+	RedactSecrets bool
+	// RedactPaths are replaced by RedactedValue before diffing and storage, changes of them alone are not traced
+	RedactPaths []Path
+	// PartialRedactPaths are masked before diffing and storage, keeping length and hash of the values
+	PartialRedactPaths []Path
+	// MaxManagedFields bounds the managedFields entries looked at to find the latest manager, zero means no limit
+	MaxManagedFields int
+	// LongStringThreshold is the length above which changed strings are diffed line by line, zero disables it
+	LongStringThreshold int
+	// OutputFormat is one of OutputColor, OutputPlain and OutputJSON, empty means OutputColor
+	OutputFormat string
+	// MergeMetadataDiff renders MetadataDiffFields, labels and annotations by default, as one metadata section
+	MergeMetadataDiff  bool
+	MetadataDiffFields []string
+	// StoredMetadataFields are the metadata fields kept in storage, "*" keeps all of them except managedFields
+	StoredMetadataFields []string
+	// IgnoreFields are metadata fields left out of diffs and storage, see DefaultIgnoredFields
+	IgnoreFields []string
+	// StampProvenance patches LastTracedAtAnnotation and TracedByVersionAnnotation onto objects with traced changes
+	StampProvenance bool
+	// RecordChangeAuthor annotates stored objects with the user, groups and time of the last change under
+	// ChangeAnnotationPrefix, the annotations are ignored when diffing
+	RecordChangeAuthor     bool
+	ChangeAnnotationPrefix string
+	// StoreBinaryData keeps the content of configmap binaryData, by default only the size and hash of each key are traced
+	StoreBinaryData bool
+	// RecordTouches records admissions without changes of TouchGVKs as empty commits, all gvks if TouchGVKs is empty
+	RecordTouches bool
+	TouchGVKs     []string
+	// KeepHistory writes a snapshot of the object before every change below HistoryDir, HistoryLimit bounds the
+	// snapshots kept per object, zero keeps all of them
+	KeepHistory  bool
+	HistoryLimit int
+	// CommitFormat is the format objects are stored in, CommitFormatYAML or CommitFormatJSON, empty means yaml
+	CommitFormat string
+	// HeaderTemplate renders the comment banner of stored yaml files, nil disables it
+	HeaderTemplate *template.Template
+	// CommitMessageTemplate renders the first line of commit messages, nil keeps the default messages
+	CommitMessageTemplate *template.Template
+	// FailurePolicy is FailureOpen or FailureClosed, requests the tracer fails on are only rejected when closed
+	FailurePolicy string
+	// LeaderElection limits git writes to the replica StartLeading has been called on, the others only log changes
+	LeaderElection bool
+	leading        atomic.Bool
+	// Version of the tracer written to TracedByVersionAnnotation
+	Version string
+	// DetectDrift compares admitted objects with the version stored in git at DriftPaths, spec if empty
+	DetectDrift bool
+	DriftPaths  []Path
+	// Dedup skips changes to a state of an object committed shortly before, nil disables it
+	Dedup *Dedup
+	// RateLimiter skips commits of objects changing faster than its rate, nil disables it
+	RateLimiter *RateLimiter
+	// ObservedIndex records the last admission of every object, nil disables it
+	ObservedIndex *ObservedIndex
+	// MaxConcurrentHandlers bounds the requests traced at once, requests waiting longer than HandlerWait for a
+	// free slot are admitted without being traced. Zero means no limit.
+	MaxConcurrentHandlers int
+	HandlerWait           time.Duration
+	sem                   chan struct{}
+	semOnce               sync.Once
+	// releases are the objects whose final state finalizer is waiting to be removed by RunFinalizerRelease
+	releases    workqueue.RateLimitingInterface
+	releaseOnce sync.Once
+	// Backends store changes next to git
+	Backends []backend.Backend
+	// DeadLetters keeps the changes neither git nor any backend could store, nil disables it
+	DeadLetters *deadletter.Store
 	GitConfig
+	FailureConfig
+	failures failureTracker
+	// gitMu serializes writes to the repository, admission requests are handled concurrently but commit and push
+	// one at a time because they share the work tree at GitPath
+	gitMu sync.Mutex
+	// pending are the changes staged since the last commit when CommitInterval is set, guarded by gitMu
+	pending []change
+	// remoteErr is the result of the last check of the git remote by RunRemoteCheck
+	remoteMu      sync.Mutex
+	remoteErr     error
+	remoteChecked bool
+	// Client is used to look up owners of intercepted objects, it can be nil if no lookup is needed
+	Client common.Client
 }
 
 type GitConfig struct {
+	GitURL    string
 	GitPath   string
 	SubPath   string
 	GitBranch string
-	GitAuth   transport.AuthMethod
+	// BranchStrategy is one of BranchSingle, BranchPerNamespace and BranchPerGVK, empty means BranchSingle
+	BranchStrategy string
+	GitAuth        transport.AuthMethod
+	// PushOptions tunes the retries of pushes
+	PushOptions git.PushOptions
+	// GitOpTimeout bounds the fetches and pushes of a change, zero leaves them bounded by the request only
+	GitOpTimeout time.Duration
+	// Identity maps kubernetes users to commit authors and sets the committer
+	Identity git.Identity
+	// GroupByApp stores objects under apps/<app> when the app label can be resolved from the owner chain
+	GroupByApp bool
+	AppLabel   string
+	// ClusterName adds a directory for the objects of this cluster below SubPath, so that many clusters can share a
+	// branch, empty stores them in SubPath
+	ClusterName string
+	// AutoRecoverRepo resets a dirty or corrupted working tree to the remote branch before committing
+	AutoRecoverRepo bool
+	// CommitInterval stages changes and commits and pushes them once per interval with RunCommitter,
+	// zero commits and pushes every change
+	CommitInterval time.Duration
+	// ReviewProvider opens a pull request from ReviewBranch into GitBranch instead of pushing to GitBranch directly
+	ReviewProvider review.Provider
+	ReviewBranch   string
+	// ReviewByAnnotation opens pull requests only for objects carrying RequiresApprovalAnnotation,
+	// the other objects are pushed to GitBranch directly
+	ReviewByAnnotation bool
 }
 
-type CustomRenderOption struct {
-	jd.Metadata
+// change is a new version of an object to be committed
+type change struct {
+	subpath      string
+	branch       string
+	user         string
+	fieldManager string
+	// subject is the first line of the commit message, empty means the default one
+	subject  string
+	data     []byte
+	trailers []git.Trailer
+	// requiresApproval is set for objects carrying RequiresApprovalAnnotation
+	requiresApproval bool
+	// touch records an admission without changes as an empty commit, data is not written
+	touch bool
+	// delete removes the stored object instead of writing data
+	delete bool
+	// created is set for objects which did not exist before the change
+	created bool
+	// history is the path of the snapshot of the object before the change, written along with the change
+	history     string
+	historyData []byte
 }
 
-func (c *CustomRenderOption) is_render_option() {}
+// operation is the git operation committing c
+func (c change) operation() string {
+	switch {
+	case c.delete:
+		return git.OperationDelete
+	case c.created:
+		return git.OperationCreate
+	}
+	return git.OperationUpdate
+}
 
 func (l *ListenerWebhook) Handle(ctx context.Context, r admission.Request) admission.Response {
+	metrics.AdmissionRequests.WithLabelValues(r.Kind.Kind, string(r.Operation)).Inc()
+
+	resp, _ := l.gate(ctx, r, l.traceAdmission)
+	return resp
+}
+
+// gate traces r with trace unless it's filtered, too large or too many requests are in flight, r is admitted without
+// tracing then. A panic of trace fails r. It reports whether r was traced.
+func (l *ListenerWebhook) gate(ctx context.Context, r admission.Request, trace func(context.Context, admission.Request) admission.Response) (resp admission.Response, traced bool) {
+	defer l.recoverPanic(&resp)
+
+	// finalizers added before the namespace was filtered still have to be released
+	if !l.traces(r) && !(l.CaptureFinalState && r.Operation == admissionv1.Delete) {
+		l.Logger.V(1).Info("object not traced, skipping", "kind", r.Kind.String(), "name", r.Name, "namespace", r.Namespace)
+		return admission.Allowed("allowed"), false
+	}
+
+	if l.oversized(r) {
+		return admission.Allowed("allowed"), false
+	}
+
+	if !l.acquire(ctx) {
+		l.Logger.Info("too many requests in flight, admitting without tracing", "name", r.Name, "namespace", r.Namespace, "resource", r.Resource.String())
+		return admission.Allowed("allowed"), false
+	}
+	defer l.release()
+
+	return trace(ctx, r), true
+}
+
+// oversized reports whether the objects of r exceed MaxObjectBytes
+func (l *ListenerWebhook) oversized(r admission.Request) bool {
+	size := max(len(r.Object.Raw), len(r.OldObject.Raw))
+	if l.MaxObjectBytes <= 0 || size <= l.MaxObjectBytes {
+		return false
+	}
+
+	l.Logger.Info("object exceeds the maximum size, admitting without tracing", "kind", r.Kind.String(), "name", r.Name, "namespace", r.Namespace, "size", size, "maxObjectBytes", l.MaxObjectBytes)
+	metrics.OversizedObjects.WithLabelValues(r.Kind.Kind).Inc()
+	return true
+}
+
+// traceAdmission traces an admission request, with CaptureFinalState it manages the final state finalizer too
+func (l *ListenerWebhook) traceAdmission(ctx context.Context, r admission.Request) (resp admission.Response) {
+	if !l.CaptureFinalState {
+		return l.handle(ctx, r)
+	}
+
+	if r.Operation == admissionv1.Delete {
+		// the deletion is recorded by the update removing the finalizer
+		if holdsFinalizer(r) {
+			l.queueRelease(r.OldObject.Raw)
+			return admission.Allowed("allowed")
+		}
+		if !l.traces(r) {
+			return admission.Allowed("allowed")
+		}
+		return l.handle(ctx, r)
+	}
+
+	resp = l.handle(ctx, r)
+	if !resp.Allowed {
+		return resp
+	}
+
+	if removal, ok := finalRemoval(r); ok {
+		l.handle(ctx, removal)
+		return resp
+	}
+
+	return l.withFinalizer(r, resp)
+}
+
+func (l *ListenerWebhook) handle(ctx context.Context, r admission.Request) admission.Response {
+	// CREATE comes without an old object and DELETE without a new one
 	obj := map[string]interface{}{}
-	if err := json.Unmarshal(r.Object.Raw, &obj); err != nil {
-		l.Logger.Error(err, "failed to unmarshal raw object")
-		return admission.Errored(400, err)
+	if len(r.Object.Raw) > 0 {
+		if err := json.Unmarshal(r.Object.Raw, &obj); err != nil {
+			l.Logger.Error(err, "failed to unmarshal raw object")
+			return l.failed(err)
+		}
 	}
 
 	oldObj := map[string]interface{}{}
-	if err := json.Unmarshal(r.OldObject.Raw, &oldObj); err != nil {
-		l.Logger.Error(err, "failed to unmarshal old raw object")
-		return admission.Errored(400, err)
+	if len(r.OldObject.Raw) > 0 {
+		if err := json.Unmarshal(r.OldObject.Raw, &oldObj); err != nil {
+			l.Logger.Error(err, "failed to unmarshal old raw object")
+			return l.failed(err)
+		}
 	}
 
-	oldRaw, err := jd.NewJsonNode(oldObj)
-	if err != nil {
-		l.Logger.Error(err, "failed to read old object")
-		return admission.Errored(400, err)
+	deleted := r.Operation == admissionv1.Delete
+	// subject is the object the change is recorded for, the old one when it's deleted
+	subject := obj
+	if deleted {
+		subject = oldObj
 	}
 
-	raw, err := jd.NewJsonNode(obj)
-	if err != nil {
-		l.Logger.Error(err, "failed to read current object")
-		return admission.Errored(400, err)
+	if len(l.AllowPaths) > 0 {
+		obj = l.allowOnly(obj)
+		oldObj = l.allowOnly(oldObj)
 	}
 
-	currentSpec, err := jd.NewJsonNode(obj["spec"])
-	if err != nil {
-		l.Logger.Error(err, "failed to read spec of current object")
-		return admission.Errored(400, err)
+	if l.IgnoreStatusChanges {
+		delete(obj, "status")
+		delete(oldObj, "status")
 	}
-	oldSpec, err := jd.NewJsonNode(oldObj["spec"])
-	if err != nil {
-		l.Logger.Error(err, "failed to read spec of old object")
-		return admission.Errored(400, err)
+
+	if l.RedactSecrets {
+		redactSecret(obj)
+		redactSecret(oldObj)
 	}
 
-	currentStatus, err := jd.NewJsonNode(obj["status"])
-	if err != nil {
-		l.Logger.Error(err, "failed to read status of current object")
-		return admission.Errored(400, err)
+	if !l.StoreBinaryData {
+		summarizeBinaryData(obj)
+		summarizeBinaryData(oldObj)
 	}
-	oldStatus, err := jd.NewJsonNode(oldObj["status"])
-	if err != nil {
-		l.Logger.Error(err, "failed to read status of old object")
-		return admission.Errored(400, err)
+
+	if l.StampProvenance {
+		dropProvenance(obj)
+		dropProvenance(oldObj)
 	}
 
-	newMetaData := obj["metadata"].(map[string]interface{})
-	oldMetadata := oldObj["metadata"].(map[string]interface{})
-	newLabels, err := jd.NewJsonNode(newMetaData["labels"])
-	if err != nil {
-		l.Logger.Error(err, "failed to read labels of current object")
-		return admission.Errored(400, err)
+	if l.RecordChangeAuthor {
+		l.dropChangeAnnotations(obj)
+		l.dropChangeAnnotations(oldObj)
 	}
 
-	oldLabels, err := jd.NewJsonNode(oldMetadata["labels"])
-	if err != nil {
-		l.Logger.Error(err, "failed to read labels of old object")
-		return admission.Errored(400, err)
+	l.redactPaths(obj)
+	l.redactPaths(oldObj)
+
+	for _, p := range l.PartialRedactPaths {
+		p.Apply(obj, partialRedact)
+		p.Apply(oldObj, partialRedact)
 	}
 
-	newAnnotations, err := jd.NewJsonNode(newMetaData["annotations"])
-	if err != nil {
-		l.Logger.Error(err, "failed to read annotations of current object")
-		return admission.Errored(400, err)
+	conversion := versionConversion(oldObj, obj)
+	if conversion != "" {
+		l.Logger.Info("apiVersion differs between old and new object", "conversion", conversion)
 	}
-	oldAnnotations, err := jd.NewJsonNode(oldMetadata["annotations"])
-	if err != nil {
-		l.Logger.Error(err, "failed to read annotations of old object")
-		return admission.Errored(400, err)
+
+	newMetaData, _ := obj["metadata"].(map[string]interface{})
+	if newMetaData == nil {
+		newMetaData = map[string]interface{}{}
+	}
+	oldMetadata, _ := oldObj["metadata"].(map[string]interface{})
+	if oldMetadata == nil {
+		oldMetadata = map[string]interface{}{}
+	}
+	subjectMetadata := newMetaData
+	if deleted {
+		subjectMetadata = oldMetadata
+	}
+	subjectNamespace, _ := subjectMetadata["namespace"].(string)
+	latestManager := resolveManager(subjectMetadata, l.MaxManagedFields, r.UserInfo.Username)
+
+	reqOpts := parseRequestOptions(r)
+
+	l.Logger.Info("Captured request", "userInfo", r.UserInfo, "operation", r.Operation, "resource", r.Resource.String(), "name", r.Name, "namespace", r.Namespace, "last updated manager", latestManager,
+		"fieldManager", reqOpts.FieldManager, "fieldValidation", reqOpts.FieldValidation)
+
+	if l.ObservedIndex != nil {
+		name, _ := subjectMetadata["name"].(string)
+		l.ObservedIndex.Observe(observedKey(subjectNamespace, buildGVK(subject), name), time.Now())
 	}
 
-	var fieldManagers []string
-	for _, f := range newMetaData["managedFields"].([]interface{}) {
-		fieldManagers = append(fieldManagers, f.(map[string]interface{})["manager"].(string))
+	if l.DetectDrift && !deleted && l.writesGit() {
+		l.detectDrift(ctx, obj, buildGVK(obj))
 	}
 
-	latestManager := fieldManagers[len(fieldManagers)-1]
+	resp := admission.Allowed("allowed")
+	// objects with the same content hash can't differ, the diff is only computed when they don't
+	var rawDiff jd.Diff
+	var sections []diffSection
+	unchanged, err := l.sameContent(oldObj, obj)
+	if err != nil {
+		l.Logger.Error(err, "failed to hash objects, diffing them")
+	}
+	if !unchanged {
+		diffStart := time.Now()
+		rawDiff, err = l.diff(nil, l.withoutIgnoredFields(oldObj), l.withoutIgnoredFields(obj))
+		if err != nil {
+			l.Logger.Error(err, "failed to diff objects")
+			return l.failed(err)
+		}
 
-	l.Logger.Info("Captured request", "userInfo", r.UserInfo, "operation", r.Operation, "resource", r.Resource.String(), "name", r.Name, "namespace", r.Namespace, "last updated manager", latestManager)
+		specDiff, err := l.diff([]string{"spec"}, sectionValue(oldObj, "spec"), sectionValue(obj, "spec"))
+		if err != nil {
+			l.Logger.Error(err, "failed to diff spec")
+			return l.failed(err)
+		}
+
+		statusDiff, err := l.diff([]string{"status"}, sectionValue(oldObj, "status"), sectionValue(obj, "status"))
+		if err != nil {
+			l.Logger.Error(err, "failed to diff status")
+			return l.failed(err)
+		}
 
-	specDiff := oldSpec.Diff(currentSpec).Render(jd.COLOR)
-	statusDiff := oldStatus.Diff(currentStatus).Render(jd.COLOR)
-	labelsDiff := oldLabels.Diff(newLabels).Render(jd.COLOR)
-	annotationsDiff := oldAnnotations.Diff(newAnnotations).Render(jd.COLOR)
+		sections = []diffSection{l.section("spec", specDiff), l.section("status", statusDiff)}
+		if l.MergeMetadataDiff {
+			metadataDiff, err := l.metadataDiff(oldMetadata, newMetaData)
+			if err != nil {
+				l.Logger.Error(err, "failed to diff metadata")
+				return l.failed(err)
+			}
+			sections = append(sections, l.section("metadata", metadataDiff))
+		} else {
+			labelsDiff, err := l.diff([]string{"metadata", "labels"}, sectionValue(oldMetadata, "labels"), sectionValue(newMetaData, "labels"))
+			if err != nil {
+				l.Logger.Error(err, "failed to diff labels")
+				return l.failed(err)
+			}
+			annotationsDiff, err := l.diff([]string{"metadata", "annotations"}, sectionValue(oldMetadata, "annotations"), sectionValue(newMetaData, "annotations"))
+			if err != nil {
+				l.Logger.Error(err, "failed to diff annotations")
+				return l.failed(err)
+			}
+			sections = append(sections, l.section("labels", labelsDiff), l.section("annotation", annotationsDiff))
+		}
+		metrics.DiffDuration.WithLabelValues(buildGVK(subject)).Observe(time.Since(diffStart).Seconds())
+	}
 
-	if specDiff == "" && statusDiff == "" && labelsDiff == "" && annotationsDiff == "" {
+	if !changed(sections) {
 		l.Logger.Info("No changes detected")
+
+		if gvk := buildGVK(subject); l.RecordTouches && l.writesGit() && l.tracesTouch(gvk) {
+			c := change{
+				subpath:      l.storagePath(ctx, subject, gvk),
+				branch:       l.branchFor(subjectNamespace, gvk),
+				user:         r.UserInfo.Username,
+				fieldManager: latestManager,
+				subject:      l.commitSubject(subject, r.Operation, r.UserInfo.Username, latestManager),
+				trailers:     append(reqOpts.trailers(), userTrailers(r.UserInfo)...),
+				touch:        true,
+			}
+			_ = l.sync(ctx, c)
+		}
 	} else {
-		fmt.Printf("spec diff: \n%s\n", specDiff)
-		fmt.Printf("status diff: \n%s\n", statusDiff)
-		fmt.Printf("labels diff: \n%s\n", labelsDiff)
-		fmt.Printf("annotation diff: \n%s\n", annotationsDiff)
+		gvk := buildGVK(subject)
+		metrics.ChangesDetected.WithLabelValues(gvk, string(r.Operation), subjectNamespace).Inc()
+		metrics.DiffBytes.WithLabelValues(gvk).Observe(float64(diffSize(sections)))
 
-		if l.Logger.V(1).Enabled() {
-			l.Logger.V(1).Info("raw diff of the whole objects")
-			fmt.Printf("raw diff: \n%s\n", oldRaw.Diff(raw).Render(jd.COLOR))
+		logger := l.Logger.WithValues("uid", r.UID, "gvk", gvk, "name", r.Name, "namespace", r.Namespace)
+		if l.OutputFormat == OutputUnified {
+			l.logUnifiedDiff(logger, conversion, l.storagePath(ctx, subject, gvk), oldObj, obj)
+		} else {
+			l.logDiffs(logger, conversion, sections, rawDiff)
 		}
 
-		if l.EnableGitReview {
-			gvk := buildGVK(obj)
-			fileName := fmt.Sprintf("%s.yaml", newMetaData["name"].(string))
-			subpath := filepath.Join(l.SubPath, newMetaData["namespace"].(string), gvk, fileName)
+		stored := false
+		var errs []error
+		var record backend.Change
+		if len(l.Backends) > 0 || l.DeadLetters != nil {
+			record = l.newRecord(r, oldObj, obj, gvk, latestManager, l.renderDiff(rawDiff), sections)
+		}
+		if len(l.Backends) > 0 {
+			stored, errs = l.store(ctx, record)
+		}
+		var failed *change
+
+		// followers are not ready, a change only reaches them before the service of the webhook notices
+		if l.EnableGitReview && !l.writesGit() {
+			logger.Info("not the leader, the change is not committed")
+			metrics.FollowerChanges.WithLabelValues(gvk).Inc()
+			errs = append(errs, errNotLeader)
+		}
+
+		if l.writesGit() && deleted {
+			c := change{
+				subpath:      l.storagePath(ctx, subject, gvk),
+				branch:       l.branchFor(subjectNamespace, gvk),
+				user:         r.UserInfo.Username,
+				fieldManager: latestManager,
+				subject:      l.commitSubject(subject, r.Operation, r.UserInfo.Username, latestManager),
+				trailers:     append(reqOpts.trailers(), userTrailers(r.UserInfo)...),
+				delete:       true,
+			}
+			if l.KeepHistory {
+				c = l.withHistory(c, oldObj, gvk)
+			}
+			if err := l.sync(ctx, c); err != nil {
+				errs = append(errs, err)
+				failed = &c
+			} else {
+				stored = true
+				if l.Dedup != nil {
+					name, _ := subjectMetadata["name"].(string)
+					l.Dedup.Forget(observedKey(subjectNamespace, gvk, name))
+				}
+			}
+		} else if l.writesGit() {
+			subpath := l.storagePath(ctx, obj, gvk)
+
+			l.prepareStored(obj)
+			annotated := obj
+			if l.RecordChangeAuthor {
+				annotated = l.withChangeAnnotations(obj, r.UserInfo, time.Now())
+			}
+			name, _ := newMetaData["name"].(string)
+			output := l.encodeStored(annotated, gvk, name, subjectNamespace)
+			metrics.StoredObjectBytes.WithLabelValues(gvk).Observe(float64(len(output)))
 
-			delete(obj["metadata"].(map[string]interface{}), "managedFields")
-			yamlOutput, err := yaml.Marshal(obj)
+			trailers := append(reqOpts.trailers(), userTrailers(r.UserInfo)...)
+			if conversion != "" {
+				trailers = append(trailers, git.Trailer{Key: conversionTrailer, Value: conversion})
+			}
+			hash, err := contentHash(obj)
 			if err != nil {
-				l.Logger.Error(err, "failed to covert to yaml output")
+				l.Logger.Error(err, "failed to compute content hash")
+			} else {
+				trailers = append(trailers, git.Trailer{Key: contentHashTrailer, Value: hash})
 			}
 
-			if err := l.syncGit(subpath, r.UserInfo.Username, latestManager, yamlOutput); err != nil {
-				l.Logger.Error(err, "failed to sync git")
+			c := change{
+				subpath:          subpath,
+				branch:           l.branchFor(subjectNamespace, gvk),
+				user:             r.UserInfo.Username,
+				fieldManager:     latestManager,
+				subject:          l.commitSubject(obj, r.Operation, r.UserInfo.Username, latestManager),
+				data:             output,
+				trailers:         trailers,
+				requiresApproval: requiresApproval(newMetaData),
+				created:          r.Operation == admissionv1.Create,
+			}
+			if l.KeepHistory && !c.created {
+				c = l.withHistory(c, oldObj, gvk)
+			}
+			objectKey := observedKey(subjectNamespace, gvk, name)
+			if l.Dedup != nil && hash != "" && l.Dedup.Seen(objectKey, hash) {
+				logger.Info("object is back to a recently committed state, skipping commit", "hash", hash)
+			} else if l.RateLimiter != nil && !l.RateLimiter.Allow(objectKey) {
+				logger.Info("object changes too often, skipping commit")
+				metrics.ThrottledChanges.WithLabelValues(gvk).Inc()
+			} else if err := l.sync(ctx, c); err != nil {
+				errs = append(errs, err)
+				failed = &c
+			} else {
+				stored = true
+				if l.Dedup != nil && hash != "" {
+					l.Dedup.Committed(objectKey, hash)
+				}
 			}
 		}
+
+		if !stored && len(errs) > 0 && l.DeadLetters != nil {
+			l.deadLetter(record, failed, errs)
+		}
+		if !stored && len(errs) > 0 && l.FailurePolicy == FailureClosed {
+			return l.failed(errors.Join(errs...))
+		}
+
+		if l.StampProvenance {
+			resp = l.withProvenance(r, resp)
+		}
 	}
 
-	return admission.Allowed("allowed")
+	return resp
 }
 
-func (l *ListenerWebhook) syncGit(subpath, userInfo, fieldManager string, data []byte) error {
-	if err := git.CommitChange(l.GitPath, subpath, userInfo, fieldManager, data, l.Logger); err != nil {
-		return fmt.Errorf("failed to commit new object: %s", err)
+func (l *ListenerWebhook) syncGit(ctx context.Context, c change) error {
+	// the staged batch leaves the work tree dirty on purpose, it must not be reset
+	if l.AutoRecoverRepo && len(l.pending) == 0 {
+		if err := git.Recover(ctx, l.GitURL, l.GitPath, l.GitBranch, l.GitAuth, l.Logger); err != nil {
+			return fmt.Errorf("failed to recover repository: %s", err)
+		}
+	}
+
+	toReview := l.ReviewProvider != nil && l.ReviewByAnnotation && c.requiresApproval
+	toBranch := c.branch != "" && c.branch != l.GitBranch
+	if toReview || toBranch {
+		// the batch is committed before switching branches so that it stays on the tracked branch
+		if err := l.commitBatch(); err != nil {
+			return err
+		}
+	}
+
+	if toReview {
+		return l.syncReviewBranch(ctx, c)
 	}
-	l.Logger.Info("git commit successfully", "author", userInfo)
 
-	if err := git.PushToRemote(l.GitPath, l.GitAuth); err != nil {
+	if toBranch {
+		return l.syncBranch(ctx, c)
+	}
+
+	if l.CommitInterval > 0 {
+		return l.stage(c)
+	}
+
+	if err := l.commit(c); err != nil {
+		return err
+	}
+
+	return l.push(ctx)
+}
+
+func (l *ListenerWebhook) commit(c change) error {
+	err := l.commitChange(c)
+	if err != nil {
+		metrics.GitCommits.WithLabelValues(metrics.ResultFailure).Inc()
+		return err
+	}
+	metrics.GitCommits.WithLabelValues(metrics.ResultSuccess).Inc()
+
+	return nil
+}
+
+func (l *ListenerWebhook) commitChange(c change) error {
+	if c.touch {
+		if err := git.CommitTouch(l.GitPath, c.subpath, c.user, c.fieldManager, c.subject, c.trailers, l.Identity); err != nil {
+			return fmt.Errorf("failed to commit touch: %s", err)
+		}
+		l.Logger.Info("git commit of touch successfully", "author", c.user)
+		return nil
+	}
+
+	if err := l.stageHistory(c); err != nil {
+		return err
+	}
+
+	if err := git.CommitChange(l.GitPath, c.subpath, c.operation(), c.user, c.fieldManager, c.subject, c.data, c.trailers, l.Identity, l.Logger); err != nil {
+		return fmt.Errorf("failed to commit %s: %s", strings.ToLower(c.operation()), err)
+	}
+	l.Logger.Info("git commit successfully", "author", c.user, "operation", c.operation())
+
+	return nil
+}
+
+// FlushPending commits changes left in the working tree and pushes the commits which didn't reach the remote
+// before the last restart, it is called before serving so that changes are delivered in order
+func (l *ListenerWebhook) FlushPending(ctx context.Context) error {
+	l.gitMu.Lock()
+	defer l.gitMu.Unlock()
+
+	if _, err := git.CommitPending(l.GitPath, l.Identity, l.Logger); err != nil {
+		return err
+	}
+
+	return l.push(ctx)
+}
+
+func (l *ListenerWebhook) push(ctx context.Context) error {
+	if l.ReviewProvider != nil && !l.ReviewByAnnotation {
+		return l.openReview(ctx)
+	}
+
+	start := time.Now()
+	err := git.PushToRemote(ctx, l.GitPath, l.GitAuth, l.PushOptions, l.Logger)
+	metrics.PushDuration.Observe(time.Since(start).Seconds())
+	if mirrorErr, ok := err.(*git.MirrorError); ok {
+		// the origin has the changes, mirrors catch up with the next push
+		l.Logger.Info("git push to remote successfully, some mirrors failed", "failedMirrors", len(mirrorErr.Errs))
+		return nil
+	}
+	if err != nil {
 		return fmt.Errorf("failed to push to remote: %s", err)
 	}
 
@@ -169,8 +671,187 @@ func (l *ListenerWebhook) syncGit(subpath, userInfo, fieldManager string, data [
 	return nil
 }
 
+func (l *ListenerWebhook) openReview(ctx context.Context) error {
+	start := time.Now()
+	err := git.PushBranch(ctx, l.GitPath, l.ReviewBranch, l.GitAuth)
+	metrics.PushDuration.Observe(time.Since(start).Seconds())
+	if err != nil {
+		return fmt.Errorf("failed to push review branch %s: %s", l.ReviewBranch, err)
+	}
+
+	url, err := l.ReviewProvider.EnsurePullRequest(ctx, review.PullRequest{
+		Title: fmt.Sprintf("Kubernetes resource changes on %s", l.GitBranch),
+		Body:  "Changes captured by k8s-resource-tracer.",
+		Head:  l.ReviewBranch,
+		Base:  l.GitBranch,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to open pull request: %s", err)
+	}
+
+	l.Logger.Info("git push to review branch successfully", "branch", l.ReviewBranch, "pullRequest", url)
+
+	return nil
+}
+
+// syncReviewBranch commits the change on the review branch and opens a pull request for it, leaving the
+// tracked branch untouched so that changes not requiring approval can still be pushed to it directly
+func (l *ListenerWebhook) syncReviewBranch(ctx context.Context, c change) (err error) {
+	base, err := git.CurrentBranch(l.GitPath)
+	if err != nil {
+		return err
+	}
+
+	if err := git.SwitchBranch(l.GitPath, l.ReviewBranch); err != nil {
+		return fmt.Errorf("failed to switch to review branch %s: %s", l.ReviewBranch, err)
+	}
+	defer func() {
+		if switchErr := git.SwitchBranch(l.GitPath, base); switchErr != nil && err == nil {
+			err = fmt.Errorf("failed to switch back to branch %s: %s", base, switchErr)
+		}
+	}()
+
+	if err := l.commit(c); err != nil {
+		return err
+	}
+
+	return l.openReview(ctx)
+}
+
+// newRecord builds the change handed to backends and the dead letter store
+func (l *ListenerWebhook) newRecord(r admission.Request, oldObj, obj map[string]interface{}, gvk, fieldManager, diff string, sections []diffSection) backend.Change {
+	// a deleted object is only known by its old state
+	metadata, ok := obj["metadata"].(map[string]interface{})
+	if !ok {
+		metadata, _ = oldObj["metadata"].(map[string]interface{})
+	}
+	namespace, _ := metadata["namespace"].(string)
+	name, _ := metadata["name"].(string)
+	c := backend.Change{
+		GVK:          gvk,
+		Namespace:    namespace,
+		Name:         name,
+		User:         r.UserInfo.Username,
+		Groups:       r.UserInfo.Groups,
+		Extra:        userExtra(r.UserInfo),
+		FieldManager: fieldManager,
+		Operation:    string(r.Operation),
+		Timestamp:    time.Now(),
+		Diff:         diff,
+		Sections:     map[string]string{},
+	}
+	for _, section := range sections {
+		if len(section.changes) > 0 {
+			c.Sections[section.name] = l.renderDiff(section.changes)
+		}
+	}
+
+	var err error
+	if c.OldObject, err = json.Marshal(oldObj); err != nil {
+		l.Logger.Error(err, "failed to marshal old object")
+	}
+	if c.NewObject, err = json.Marshal(obj); err != nil {
+		l.Logger.Error(err, "failed to marshal current object")
+	}
+
+	return c
+}
+
+// store records the change in every backend, it reports whether any of them succeeded
+func (l *ListenerWebhook) store(ctx context.Context, c backend.Change) (bool, []error) {
+	stored := false
+	var errs []error
+	for _, b := range l.Backends {
+		if err := b.Store(ctx, c); err != nil {
+			l.Logger.Error(err, "failed to store change", "backend", fmt.Sprintf("%T", b))
+			errs = append(errs, err)
+			continue
+		}
+		stored = true
+	}
+
+	return stored, errs
+}
+
+// deadLetter persists a change which no destination could store
+func (l *ListenerWebhook) deadLetter(record backend.Change, c *change, errs []error) {
+	rec := deadletter.Record{Change: record, FailedAt: time.Now()}
+	if c != nil {
+		rec.Git = &deadletter.GitChange{
+			Subpath:      c.subpath,
+			User:         c.user,
+			FieldManager: c.fieldManager,
+			Subject:      c.subject,
+			Operation:    c.operation(),
+			Data:         c.data,
+			Trailers:     c.trailers,
+		}
+	}
+	for _, err := range errs {
+		rec.Errors = append(rec.Errors, err.Error())
+	}
+
+	if err := l.DeadLetters.Put(rec); err != nil {
+		l.Logger.Error(err, "failed to dead letter change, it is lost", "gvk", record.GVK, "name", record.Name, "namespace", record.Namespace)
+		return
+	}
+	l.Logger.Info("stored change in dead letter store", "gvk", record.GVK, "name", record.Name, "namespace", record.Namespace)
+}
+
+// ClusterScopeDir takes the place of the namespace in the path of cluster scoped objects, namespace names can't
+// start with an underscore
+const ClusterScopeDir = "_cluster"
+
+// storageRoot is the directory holding the objects of this cluster relative to the repository
+func (l *ListenerWebhook) storageRoot() string {
+	if l.ClusterName == "" {
+		return l.SubPath
+	}
+	return filepath.Join(l.SubPath, escapeName(l.ClusterName))
+}
+
+// storagePath returns the path of the file holding obj relative to the repository
+func (l *ListenerWebhook) storagePath(ctx context.Context, obj map[string]interface{}, gvk string) string {
+	metadata, _ := obj["metadata"].(map[string]interface{})
+	name, _ := metadata["name"].(string)
+	namespace, _ := metadata["namespace"].(string)
+	fileName := escapeName(name) + l.fileExtension()
+	if namespace == "" {
+		namespace = ClusterScopeDir
+	} else {
+		namespace = escapeName(namespace)
+	}
+	root := l.storageRoot()
+	if l.GroupByApp {
+		if app := l.resolveApp(ctx, obj); app != "" {
+			return filepath.Join(root, "apps", escapeName(app), namespace, gvk, fileName)
+		}
+	}
+
+	return filepath.Join(root, namespace, gvk, fileName)
+}
+
+// tracesTouch reports whether admissions without changes are recorded for gvk, all gvks are if none is configured
+func (l *ListenerWebhook) tracesTouch(gvk string) bool {
+	if len(l.TouchGVKs) == 0 {
+		return true
+	}
+	for _, g := range l.TouchGVKs {
+		if g == gvk {
+			return true
+		}
+	}
+	return false
+}
+
+func requiresApproval(metadata map[string]interface{}) bool {
+	annotations, _ := metadata["annotations"].(map[string]interface{})
+	return annotations[RequiresApprovalAnnotation] == "true"
+}
+
 func buildGVK(obj map[string]interface{}) string {
-	apiVersion := obj["apiVersion"].(string)
+	apiVersion, _ := obj["apiVersion"].(string)
+	kind, _ := obj["kind"].(string)
 	gv := strings.ReplaceAll(apiVersion, "/", "-")
-	return fmt.Sprintf("%s.%s", gv, obj["kind"].(string))
+	return fmt.Sprintf("%s.%s", gv, kind)
 }
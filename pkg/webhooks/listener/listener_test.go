@@ -0,0 +1,118 @@
+package listener
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	gg "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	admissionv1 "k8s.io/api/admission/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestHandleClusterScoped(t *testing.T) {
+	l := newTestListener()
+	l.EnableGitReview = true
+	l.StoredMetadataFields = DefaultStoredMetadataFields
+	l.GitPath = t.TempDir()
+	repo, err := gg.PlainInit(l.GitPath, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	clusterRole := map[string]interface{}{
+		"apiVersion": "rbac.authorization.k8s.io/v1",
+		"kind":       "ClusterRole",
+		"metadata":   map[string]interface{}{"name": "reader", "resourceVersion": "1", "labels": map[string]interface{}{"team": "platform"}},
+		"rules":      []interface{}{map[string]interface{}{"verbs": []interface{}{"get"}}},
+	}
+	r := request(t, admissionv1.Create, nil, clusterRole)
+	r.Kind = metav1.GroupVersionKind{Group: "rbac.authorization.k8s.io", Version: "v1", Kind: "ClusterRole"}
+	r.Resource = metav1.GroupVersionResource{Group: "rbac.authorization.k8s.io", Version: "v1", Resource: "clusterroles"}
+	r.Name, r.Namespace = "reader", ""
+
+	if resp := l.handle(context.Background(), r); !resp.Allowed {
+		t.Fatalf("expected the request to be allowed, got %+v", resp.Result)
+	}
+
+	head, err := repo.Head()
+	if err != nil {
+		t.Fatalf("expected a commit, got %s", err)
+	}
+	commit, err := repo.CommitObject(head.Hash())
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(ClusterScopeDir, "rbac.authorization.k8s.io-v1.ClusterRole", "reader.yaml")
+	f, err := commit.File(path)
+	if err != nil {
+		t.Fatalf("expected %s to be committed: %s", path, err)
+	}
+	contents, err := f.Contents()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(contents, "name: reader") {
+		t.Errorf("expected the cluster role to be stored, got %q", contents)
+	}
+}
+
+func TestHandleConcurrently(t *testing.T) {
+	l, repo, remote := gitListener(t)
+
+	const n = 20
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			obj := deployment(map[string]interface{}{"replicas": int64(i)})
+			obj["metadata"].(map[string]interface{})["name"] = fmt.Sprintf("app-%d", i)
+			r := request(t, admissionv1.Create, nil, obj)
+			r.Name = fmt.Sprintf("app-%d", i)
+			if resp := l.handle(context.Background(), r); !resp.Allowed {
+				t.Errorf("expected request to be allowed, got %v", resp.Result)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	head, err := repo.Head()
+	if err != nil {
+		t.Fatal(err)
+	}
+	commits, err := repo.Log(&gg.LogOptions{From: head.Hash()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	count := 0
+	if err := commits.ForEach(func(*object.Commit) error { count++; return nil }); err != nil {
+		t.Fatal(err)
+	}
+	if count != n {
+		t.Errorf("expected %d commits, got %d", n, count)
+	}
+
+	commit, err := repo.CommitObject(head.Hash())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < n; i++ {
+		path := filepath.Join("default", "apps-v1.Deployment", fmt.Sprintf("app-%d.yaml", i))
+		if _, err := commit.File(path); err != nil {
+			t.Errorf("expected %s in the last commit: %s", path, err)
+		}
+	}
+
+	pushed, err := remote.Reference(head.Name(), true)
+	if err != nil {
+		t.Fatalf("expected the branch to be pushed: %s", err)
+	}
+	if pushed.Hash() != head.Hash() {
+		t.Errorf("expected the remote at %s, got %s", head.Hash(), pushed.Hash())
+	}
+}
@@ -0,0 +1,29 @@
+package listener
+
+const (
+	unknownManager = "unknown"
+
+	DefaultMaxManagedFields = 100
+)
+
+// resolveManager returns the manager of the last well-formed managedFields entry. Entries are scanned from the end,
+// at most max of them are looked at, zero means no limit. Objects without managedFields, e.g. written by tools
+// stripping them, fall back to the requesting user and then to unknownManager.
+func resolveManager(metadata map[string]interface{}, max int, username string) string {
+	entries, _ := metadata["managedFields"].([]interface{})
+	for i := len(entries) - 1; i >= 0; i-- {
+		if max > 0 && len(entries)-i > max {
+			break
+		}
+
+		entry, _ := entries[i].(map[string]interface{})
+		if manager, ok := entry["manager"].(string); ok && manager != "" {
+			return manager
+		}
+	}
+
+	if username != "" {
+		return username
+	}
+	return unknownManager
+}
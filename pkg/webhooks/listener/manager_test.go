@@ -0,0 +1,90 @@
+package listener
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	admissionv1 "k8s.io/api/admission/v1"
+
+	"github.com/reborn1867/k8s-resource-tracer/pkg/backend"
+)
+
+func TestHandleWithoutManagedFields(t *testing.T) {
+	cases := []struct {
+		name          string
+		managedFields interface{}
+		username      string
+		want          string
+	}{
+		{name: "absent", username: "alice", want: "alice"},
+		{name: "empty", managedFields: []interface{}{}, username: "alice", want: "alice"},
+		{name: "absent without user", want: unknownManager},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			out := &bytes.Buffer{}
+			l := newTestListener()
+			l.Backends = []backend.Backend{backend.NewStdoutBackend(out)}
+
+			old := deployment(map[string]interface{}{"replicas": int64(1)})
+			obj := deployment(map[string]interface{}{"replicas": int64(2)})
+			if c.managedFields != nil {
+				obj["metadata"].(map[string]interface{})["managedFields"] = c.managedFields
+			}
+			r := request(t, admissionv1.Update, old, obj)
+			r.UserInfo.Username = c.username
+
+			if resp := l.handle(context.Background(), r); !resp.Allowed {
+				t.Fatalf("expected request to be allowed, got %v", resp.Result)
+			}
+			cs := changes(t, out)
+			if len(cs) != 1 {
+				t.Fatalf("expected one change, got %d", len(cs))
+			}
+			if cs[0].FieldManager != c.want {
+				t.Errorf("expected manager %q, got %q", c.want, cs[0].FieldManager)
+			}
+		})
+	}
+}
+
+func TestResolveManager(t *testing.T) {
+	entry := func(manager interface{}) interface{} {
+		return map[string]interface{}{"manager": manager, "operation": "Update"}
+	}
+	// the latest entry is last, older entries are filled with another manager
+	oversized := make([]interface{}, 0, 1001)
+	for i := 0; i < 1000; i++ {
+		oversized = append(oversized, entry("old-controller"))
+	}
+	oversized = append(oversized, entry("kubectl"))
+
+	cases := []struct {
+		name          string
+		managedFields interface{}
+		max           int
+		want          string
+	}{
+		{name: "latest entry", managedFields: []interface{}{entry("helm"), entry("kubectl")}, want: "kubectl"},
+		{name: "entry without manager", managedFields: []interface{}{entry("helm"), map[string]interface{}{"operation": "Update"}}, want: "helm"},
+		{name: "manager not a string", managedFields: []interface{}{entry("helm"), entry(42)}, want: "helm"},
+		{name: "empty manager", managedFields: []interface{}{entry("helm"), entry("")}, want: "helm"},
+		{name: "entry not a map", managedFields: []interface{}{entry("helm"), "kubectl"}, want: "helm"},
+		{name: "managedFields not a list", managedFields: map[string]interface{}{"manager": "kubectl"}, want: "alice"},
+		{name: "only malformed entries", managedFields: []interface{}{nil, 1, entry(nil)}, want: "alice"},
+		{name: "oversized", managedFields: oversized, max: DefaultMaxManagedFields, want: "kubectl"},
+		{name: "well-formed entry beyond the limit", managedFields: []interface{}{entry("helm"), entry(nil), entry(nil)}, max: 2, want: "alice"},
+		{name: "no limit", managedFields: []interface{}{entry("helm"), entry(nil), entry(nil)}, want: "helm"},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			metadata := map[string]interface{}{"managedFields": c.managedFields}
+			if got := resolveManager(metadata, c.max, "alice"); got != c.want {
+				t.Errorf("expected %q, got %q", c.want, got)
+			}
+		})
+	}
+}
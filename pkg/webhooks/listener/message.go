@@ -0,0 +1,66 @@
+package listener
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"text/template"
+
+	admissionv1 "k8s.io/api/admission/v1"
+)
+
+// messageData holds the fields available in commit message templates
+type messageData struct {
+	User         string
+	FieldManager string
+	Namespace    string
+	Kind         string
+	Name         string
+	Operation    string
+}
+
+// ParseCommitMessageTemplate parses a commit message template, an empty template keeps the default messages.
+// The template is rendered once so that unknown fields are reported at startup.
+func ParseCommitMessageTemplate(text string) (*template.Template, error) {
+	if text == "" {
+		return nil, nil
+	}
+
+	t, err := template.New("message").Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, err
+	}
+	if err := t.Execute(io.Discard, messageData{}); err != nil {
+		return nil, err
+	}
+
+	return t, nil
+}
+
+// commitSubject renders the first line of the commit message of a change of obj, empty means the default message.
+// A template failing to render falls back to the default message rather than losing the change.
+func (l *ListenerWebhook) commitSubject(obj map[string]interface{}, operation admissionv1.Operation, user, fieldManager string) string {
+	if l.CommitMessageTemplate == nil {
+		return ""
+	}
+
+	metadata, _ := obj["metadata"].(map[string]interface{})
+	name, _ := metadata["name"].(string)
+	namespace, _ := metadata["namespace"].(string)
+	kind, _ := obj["kind"].(string)
+
+	out := bytes.Buffer{}
+	if err := l.CommitMessageTemplate.Execute(&out, messageData{
+		User:         user,
+		FieldManager: fieldManager,
+		Namespace:    namespace,
+		Kind:         kind,
+		Name:         name,
+		Operation:    string(operation),
+	}); err != nil {
+		l.Logger.Error(err, "failed to render commit message, using the default one")
+		return ""
+	}
+
+	return strings.TrimSpace(out.String())
+}
@@ -0,0 +1,105 @@
+package listener
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+)
+
+// ObservedIndex keeps the last time every object was admitted, with or without changes, in a file next to the
+// repository so that objects still being reconciled can be told apart without a commit per admission
+type ObservedIndex struct {
+	path string
+
+	mu       sync.Mutex
+	observed map[string]time.Time
+	dirty    bool
+}
+
+// NewObservedIndex loads the index at path, a missing file starts an empty index
+func NewObservedIndex(path string) (*ObservedIndex, error) {
+	idx := &ObservedIndex{path: path, observed: map[string]time.Time{}}
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return idx, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read observed index, path: %s, err: %s", path, err)
+	}
+
+	if err := json.Unmarshal(data, &idx.observed); err != nil {
+		return nil, fmt.Errorf("failed to parse observed index, path: %s, err: %s", path, err)
+	}
+
+	return idx, nil
+}
+
+// Observe records that the object identified by key was admitted at t
+func (idx *ObservedIndex) Observe(key string, t time.Time) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	idx.observed[key] = t.UTC().Truncate(time.Second)
+	idx.dirty = true
+}
+
+// Run writes the index every interval until ctx is done, admissions only update it in memory
+func (idx *ObservedIndex) Run(ctx context.Context, interval time.Duration, logger logr.Logger) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			if err := idx.flush(); err != nil {
+				logger.Error(err, "failed to write observed index", "path", idx.path)
+			}
+			return
+		case <-ticker.C:
+			if err := idx.flush(); err != nil {
+				logger.Error(err, "failed to write observed index", "path", idx.path)
+			}
+		}
+	}
+}
+
+func (idx *ObservedIndex) flush() error {
+	idx.mu.Lock()
+	if !idx.dirty {
+		idx.mu.Unlock()
+		return nil
+	}
+	data, err := json.MarshalIndent(idx.observed, "", "  ")
+	idx.dirty = false
+	idx.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(idx.path), os.ModePerm); err != nil {
+		return err
+	}
+
+	// write a temporary file first so a crash never leaves a truncated index
+	tmp := idx.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp, idx.path)
+}
+
+// observedKey identifies an object in the observed index
+func observedKey(namespace, gvk, name string) string {
+	if namespace == "" {
+		return gvk + "/" + name
+	}
+	return namespace + "/" + gvk + "/" + name
+}
@@ -0,0 +1,73 @@
+package listener
+
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+
+	authenticationv1 "k8s.io/api/authentication/v1"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	"github.com/reborn1867/k8s-resource-tracer/pkg/git"
+)
+
+// requestOptions holds the fields shared by CreateOptions, UpdateOptions and PatchOptions,
+// DeleteOptions carries neither of them
+type requestOptions struct {
+	FieldManager    string `json:"fieldManager,omitempty"`
+	FieldValidation string `json:"fieldValidation,omitempty"`
+}
+
+func parseRequestOptions(r admission.Request) requestOptions {
+	opts := requestOptions{}
+	if len(r.Options.Raw) == 0 {
+		return opts
+	}
+
+	// options of an unexpected type are ignored, they are only informational
+	_ = json.Unmarshal(r.Options.Raw, &opts)
+	return opts
+}
+
+func (o requestOptions) trailers() []git.Trailer {
+	var trailers []git.Trailer
+	if o.FieldManager != "" {
+		trailers = append(trailers, git.Trailer{Key: "Field-Manager", Value: o.FieldManager})
+	}
+	if o.FieldValidation != "" {
+		trailers = append(trailers, git.Trailer{Key: "Field-Validation", Value: o.FieldValidation})
+	}
+
+	return trailers
+}
+
+// userTrailers record the groups and the extra claims of the user, the author of the commit is the user name
+func userTrailers(user authenticationv1.UserInfo) []git.Trailer {
+	var trailers []git.Trailer
+	if len(user.Groups) > 0 {
+		trailers = append(trailers, git.Trailer{Key: "Groups", Value: strings.Join(user.Groups, ",")})
+	}
+
+	keys := make([]string, 0, len(user.Extra))
+	for k := range user.Extra {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		trailers = append(trailers, git.Trailer{Key: "User-Extra", Value: k + "=" + strings.Join(user.Extra[k], ",")})
+	}
+
+	return trailers
+}
+
+// userExtra converts the extra claims of user to plain string lists, nil if there are none
+func userExtra(user authenticationv1.UserInfo) map[string][]string {
+	if len(user.Extra) == 0 {
+		return nil
+	}
+	extra := make(map[string][]string, len(user.Extra))
+	for k, v := range user.Extra {
+		extra[k] = v
+	}
+	return extra
+}
@@ -0,0 +1,138 @@
+package listener
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/go-logr/logr"
+	jd "github.com/josephburnett/jd/lib"
+	"gopkg.in/yaml.v2"
+)
+
+// Output formats of the diffs of traced changes
+const (
+	OutputColor = "color"
+	OutputPlain = "plain"
+	OutputJSON  = "json"
+	// OutputUnified logs one diff of the old and new yaml of objects in the format of git diff
+	OutputUnified = "unified"
+)
+
+// diffChange is a single change of a diff as logged by OutputJSON
+type diffChange struct {
+	Path []interface{} `json:"path"`
+	Old  interface{}   `json:"old,omitempty"`
+	New  interface{}   `json:"new,omitempty"`
+}
+
+// ValidateOutputFormat returns an error for unknown output formats, empty means OutputColor
+func ValidateOutputFormat(format string) error {
+	switch format {
+	case "", OutputColor, OutputPlain, OutputJSON, OutputUnified:
+		return nil
+	}
+	return fmt.Errorf("unknown output format %s, expected %s, %s, %s or %s", format, OutputColor, OutputPlain, OutputJSON, OutputUnified)
+}
+
+func (l *ListenerWebhook) renderOptions() []jd.RenderOption {
+	if l.OutputFormat == "" || l.OutputFormat == OutputColor {
+		return []jd.RenderOption{jd.COLOR}
+	}
+	return nil
+}
+
+// section renders d with the options of the output format
+func (l *ListenerWebhook) section(name string, d jd.Diff) diffSection {
+	return diffSection{name: name, diff: l.renderDiff(d, l.renderOptions()...), changes: d}
+}
+
+// logDiffs logs the diff of every changed section of a traced change, with OutputJSON the changes are logged as
+// structured fields instead of rendered text
+func (l *ListenerWebhook) logDiffs(logger logr.Logger, conversion string, sections []diffSection, raw jd.Diff) {
+	if conversion != "" {
+		logger.Info("version conversion", "conversion", conversion)
+	}
+	for _, section := range sections {
+		if len(section.changes) == 0 {
+			continue
+		}
+		key, value := l.diffField(section.changes, section.diff)
+		logger.Info("diff", "section", section.name, key, value)
+	}
+
+	if logger.V(1).Enabled() {
+		key, value := l.diffField(raw, l.renderDiff(raw, l.renderOptions()...))
+		logger.V(1).Info("raw diff of the whole objects", key, value)
+	}
+}
+
+// diffField returns the key and value a diff is logged with
+func (l *ListenerWebhook) diffField(d jd.Diff, rendered string) (string, interface{}) {
+	if l.OutputFormat == OutputJSON {
+		return "changes", diffChanges(d)
+	}
+	return "diff", rendered
+}
+
+func diffChanges(d jd.Diff) []diffChange {
+	changes := make([]diffChange, 0, len(d))
+	for _, e := range d {
+		path := make([]interface{}, 0, len(e.Path))
+		for _, p := range e.Path {
+			path = append(path, decodeNode(p))
+		}
+		changes = append(changes, diffChange{Path: path, Old: decodeValues(e.OldValues), New: decodeValues(e.NewValues)})
+	}
+	return changes
+}
+
+// decodeValues returns nil for no value, the value itself for one and a list otherwise
+func decodeValues(nodes []jd.JsonNode) interface{} {
+	switch len(nodes) {
+	case 0:
+		return nil
+	case 1:
+		return decodeNode(nodes[0])
+	}
+
+	values := make([]interface{}, 0, len(nodes))
+	for _, n := range nodes {
+		values = append(values, decodeNode(n))
+	}
+	return values
+}
+
+func decodeNode(n jd.JsonNode) interface{} {
+	var v interface{}
+	if err := json.Unmarshal([]byte(n.Json()), &v); err != nil {
+		return n.Json()
+	}
+	return v
+}
+
+// logUnifiedDiff logs the change of the yaml of the object at path, the way OutputUnified renders it
+func (l *ListenerWebhook) logUnifiedDiff(logger logr.Logger, conversion, path string, oldObj, obj map[string]interface{}) {
+	if conversion != "" {
+		logger.Info("version conversion", "conversion", conversion)
+	}
+
+	yamlText := func(o map[string]interface{}) (string, error) {
+		if len(o) == 0 {
+			return "", nil
+		}
+		out, err := yaml.Marshal(l.withoutIgnoredFields(o))
+		return string(out), err
+	}
+	oldText, err := yamlText(oldObj)
+	if err != nil {
+		l.Logger.Error(err, "failed to convert old object to yaml")
+		return
+	}
+	newText, err := yamlText(obj)
+	if err != nil {
+		l.Logger.Error(err, "failed to convert current object to yaml")
+		return
+	}
+
+	logger.Info("diff", "diff", unifiedPatch(path, oldText, newText))
+}
@@ -0,0 +1,206 @@
+package listener
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+type segmentKind int
+
+const (
+	keySegment segmentKind = iota
+	indexSegment
+	anyIndexSegment
+)
+
+type pathSegment struct {
+	kind  segmentKind
+	key   string
+	index int
+}
+
+// Path is a parsed field path like spec.template.spec.containers[*].env or metadata.annotations["example.com/token"].
+// A "*" key matches every value of a map, [*] matches every item of a list.
+type Path struct {
+	raw      string
+	segments []pathSegment
+}
+
+func (p Path) String() string {
+	return p.raw
+}
+
+func ParsePath(raw string) (Path, error) {
+	path := Path{raw: raw}
+	if raw == "" {
+		return path, fmt.Errorf("empty path")
+	}
+
+	expectKey := true
+	for i := 0; i < len(raw); {
+		switch raw[i] {
+		case '.':
+			if expectKey {
+				return path, fmt.Errorf("empty key at position %d in path %s", i, raw)
+			}
+			expectKey = true
+			i++
+		case '[':
+			end := strings.IndexByte(raw[i:], ']')
+			if end < 0 {
+				return path, fmt.Errorf("unclosed [ at position %d in path %s", i, raw)
+			}
+
+			seg, err := parseBracket(raw[i+1 : i+end])
+			if err != nil {
+				return path, fmt.Errorf("invalid path %s: %s", raw, err)
+			}
+			path.segments = append(path.segments, seg)
+			expectKey = false
+			i += end + 1
+		default:
+			if !expectKey {
+				return path, fmt.Errorf("unexpected %q at position %d in path %s", raw[i], i, raw)
+			}
+
+			j := i
+			for j < len(raw) && raw[j] != '.' && raw[j] != '[' {
+				j++
+			}
+			path.segments = append(path.segments, pathSegment{kind: keySegment, key: raw[i:j]})
+			expectKey = false
+			i = j
+		}
+	}
+
+	if expectKey {
+		return path, fmt.Errorf("path %s ends with an empty key", raw)
+	}
+
+	return path, nil
+}
+
+func parseBracket(inner string) (pathSegment, error) {
+	if inner == "*" {
+		return pathSegment{kind: anyIndexSegment}, nil
+	}
+
+	if len(inner) >= 2 && (inner[0] == '"' || inner[0] == '\'') && inner[len(inner)-1] == inner[0] {
+		return pathSegment{kind: keySegment, key: inner[1 : len(inner)-1]}, nil
+	}
+
+	index, err := strconv.Atoi(inner)
+	if err != nil || index < 0 {
+		return pathSegment{}, fmt.Errorf("invalid index [%s]", inner)
+	}
+
+	return pathSegment{kind: indexSegment, index: index}, nil
+}
+
+// Apply replaces every value matched by the path with the result of fn, fields missing in obj are skipped
+func (p Path) Apply(obj map[string]interface{}, fn func(interface{}) interface{}) {
+	applySegments(obj, p.segments, fn)
+}
+
+func applySegments(node interface{}, segments []pathSegment, fn func(interface{}) interface{}) interface{} {
+	if len(segments) == 0 {
+		return fn(node)
+	}
+
+	seg, rest := segments[0], segments[1:]
+	switch n := node.(type) {
+	case map[string]interface{}:
+		if seg.kind != keySegment {
+			return node
+		}
+
+		if seg.key == "*" {
+			for k, v := range n {
+				n[k] = applySegments(v, rest, fn)
+			}
+		} else if v, ok := n[seg.key]; ok {
+			n[seg.key] = applySegments(v, rest, fn)
+		}
+	case []interface{}:
+		switch seg.kind {
+		case anyIndexSegment:
+			for i, v := range n {
+				n[i] = applySegments(v, rest, fn)
+			}
+		case indexSegment:
+			if seg.index < len(n) {
+				n[seg.index] = applySegments(n[seg.index], rest, fn)
+			}
+		}
+	}
+
+	return node
+}
+
+// Project copies the values matched by the path from src into dst, creating their parents in dst on the way.
+// Lists keep their length, items without a match are left empty.
+func (p Path) Project(src, dst map[string]interface{}) {
+	projectSegments(src, dst, p.segments)
+}
+
+func projectSegments(src, dst interface{}, segments []pathSegment) interface{} {
+	if len(segments) == 0 {
+		return src
+	}
+
+	seg, rest := segments[0], segments[1:]
+	switch s := src.(type) {
+	case map[string]interface{}:
+		if seg.kind != keySegment {
+			return dst
+		}
+
+		var keys []string
+		if seg.key == "*" {
+			for k := range s {
+				keys = append(keys, k)
+			}
+		} else if _, ok := s[seg.key]; ok {
+			keys = []string{seg.key}
+		}
+		if len(keys) == 0 {
+			return dst
+		}
+
+		d, _ := dst.(map[string]interface{})
+		if d == nil {
+			d = map[string]interface{}{}
+		}
+		for _, k := range keys {
+			d[k] = projectSegments(s[k], d[k], rest)
+		}
+		return d
+	case []interface{}:
+		var indexes []int
+		switch seg.kind {
+		case anyIndexSegment:
+			for i := range s {
+				indexes = append(indexes, i)
+			}
+		case indexSegment:
+			if seg.index < len(s) {
+				indexes = []int{seg.index}
+			}
+		}
+		if len(indexes) == 0 {
+			return dst
+		}
+
+		d, _ := dst.([]interface{})
+		if len(d) != len(s) {
+			d = make([]interface{}, len(s))
+		}
+		for _, i := range indexes {
+			d[i] = projectSegments(s[i], d[i], rest)
+		}
+		return d
+	}
+
+	return dst
+}
@@ -0,0 +1,40 @@
+package listener
+
+import (
+	"fmt"
+
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+// Failure policies deciding whether requests are admitted when tracing them fails
+const (
+	FailureOpen   = "open"
+	FailureClosed = "closed"
+)
+
+// ValidateFailurePolicy returns an error for unknown failure policies, empty means FailureOpen
+func ValidateFailurePolicy(policy string) error {
+	switch policy {
+	case "", FailureOpen, FailureClosed:
+		return nil
+	}
+	return fmt.Errorf("unknown failure policy %s, expected %s or %s", policy, FailureOpen, FailureClosed)
+}
+
+// failed answers a request the tracer failed on, it is admitted unless the failure policy is closed
+func (l *ListenerWebhook) failed(err error) admission.Response {
+	if l.FailurePolicy == FailureClosed {
+		return admission.Errored(500, err)
+	}
+	l.Logger.Info("admitting request despite tracer failure", "failurePolicy", FailureOpen)
+	return admission.Allowed(fmt.Sprintf("allowed, tracing failed: %s", err))
+}
+
+// recoverPanic turns a panic of the tracer into a failed response
+func (l *ListenerWebhook) recoverPanic(resp *admission.Response) {
+	if p := recover(); p != nil {
+		err := fmt.Errorf("tracer panicked: %v", p)
+		l.Logger.Error(err, "failed to trace request")
+		*resp = l.failed(err)
+	}
+}
@@ -0,0 +1,142 @@
+package listener
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+
+	"gomodules.xyz/jsonpatch/v2"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+const (
+	// LastTracedAtAnnotation holds the time the last change of an object was traced
+	LastTracedAtAnnotation = "tracer.io/last-traced-at"
+	// TracedByVersionAnnotation holds the version of the tracer which traced the last change
+	TracedByVersionAnnotation = "tracer.io/traced-by-version"
+
+	// DefaultChangeAnnotationPrefix prefixes the annotations recording the author of a change in stored objects
+	DefaultChangeAnnotationPrefix = "tracer.k8s"
+	lastChangedByAnnotation       = "last-changed-by"
+	lastChangedByGroupsAnnotation = "last-changed-by-groups"
+	lastChangedByExtraAnnotation  = "last-changed-by-extra"
+	lastChangeTimeAnnotation      = "last-change-time"
+
+	// maxAnnotationsBytes is the limit enforced by the api server on the total size of annotations
+	maxAnnotationsBytes = 256 * 1024
+)
+
+// dropProvenance removes the provenance annotations from obj, they are written by the tracer itself and
+// must neither show up as changes nor end up in storage
+func dropProvenance(obj map[string]interface{}) {
+	metadata, _ := obj["metadata"].(map[string]interface{})
+	annotations, _ := metadata["annotations"].(map[string]interface{})
+	delete(annotations, LastTracedAtAnnotation)
+	delete(annotations, TracedByVersionAnnotation)
+	if annotations != nil && len(annotations) == 0 {
+		delete(metadata, "annotations")
+	}
+}
+
+// dropChangeAnnotations removes the annotations starting with ChangeAnnotationPrefix from obj, they end up in the
+// live object when a stored file is applied and would otherwise be traced as a change of their own
+func (l *ListenerWebhook) dropChangeAnnotations(obj map[string]interface{}) {
+	metadata, _ := obj["metadata"].(map[string]interface{})
+	annotations, _ := metadata["annotations"].(map[string]interface{})
+	for k := range annotations {
+		if strings.HasPrefix(k, l.ChangeAnnotationPrefix+"/") {
+			delete(annotations, k)
+		}
+	}
+	if annotations != nil && len(annotations) == 0 {
+		delete(metadata, "annotations")
+	}
+}
+
+// withChangeAnnotations returns a copy of obj annotated with the user, the groups, the extra claims as json and the
+// time of the change, obj is left intact so that content hashes don't change with every request
+func (l *ListenerWebhook) withChangeAnnotations(obj map[string]interface{}, user authenticationv1.UserInfo, at time.Time) map[string]interface{} {
+	metadata, _ := obj["metadata"].(map[string]interface{})
+	existing, _ := metadata["annotations"].(map[string]interface{})
+
+	annotations := make(map[string]interface{}, len(existing)+4)
+	for k, v := range existing {
+		annotations[k] = v
+	}
+	annotations[l.ChangeAnnotationPrefix+"/"+lastChangedByAnnotation] = user.Username
+	if len(user.Groups) > 0 {
+		annotations[l.ChangeAnnotationPrefix+"/"+lastChangedByGroupsAnnotation] = strings.Join(user.Groups, ",")
+	}
+	if extra := userExtra(user); extra != nil {
+		if data, err := json.Marshal(extra); err == nil {
+			annotations[l.ChangeAnnotationPrefix+"/"+lastChangedByExtraAnnotation] = string(data)
+		}
+	}
+	annotations[l.ChangeAnnotationPrefix+"/"+lastChangeTimeAnnotation] = at.UTC().Format(time.RFC3339)
+
+	annotated := make(map[string]interface{}, len(metadata)+1)
+	for k, v := range metadata {
+		annotated[k] = v
+	}
+	annotated["annotations"] = annotations
+
+	copied := make(map[string]interface{}, len(obj))
+	for k, v := range obj {
+		copied[k] = v
+	}
+	copied["metadata"] = annotated
+
+	return copied
+}
+
+// withProvenance patches the provenance annotations onto the object, this relies on the tracer being registered
+// as a mutating webhook. The timestamp has a precision of seconds so reinvocations of the webhook within the
+// same request produce the same patch.
+func (l *ListenerWebhook) withProvenance(r admission.Request, resp admission.Response) admission.Response {
+	obj := &unstructured.Unstructured{}
+	if err := obj.UnmarshalJSON(r.Object.Raw); err != nil {
+		l.Logger.Error(err, "failed to read object for provenance annotations")
+		return resp
+	}
+
+	stamp := map[string]string{
+		LastTracedAtAnnotation:    time.Now().UTC().Truncate(time.Second).Format(time.RFC3339),
+		TracedByVersionAnnotation: l.Version,
+	}
+
+	annotations := obj.GetAnnotations()
+	size := 0
+	for k, v := range annotations {
+		if _, ok := stamp[k]; !ok {
+			size += len(k) + len(v)
+		}
+	}
+	for k, v := range stamp {
+		size += len(k) + len(v)
+	}
+	if size > maxAnnotationsBytes {
+		l.Logger.Info("skipping provenance annotations, annotations would exceed the size limit", "name", obj.GetName(), "namespace", obj.GetNamespace(), "size", size)
+		return resp
+	}
+
+	if len(annotations) == 0 {
+		resp.Patches = append(resp.Patches, jsonpatch.NewOperation("add", "/metadata/annotations", stamp))
+		return resp
+	}
+
+	for k, v := range stamp {
+		if annotations[k] == v {
+			continue
+		}
+		resp.Patches = append(resp.Patches, jsonpatch.NewOperation("add", "/metadata/annotations/"+escapePointer(k), v))
+	}
+
+	return resp
+}
+
+// escapePointer escapes a key for use in a json pointer
+func escapePointer(key string) string {
+	return strings.NewReplacer("~", "~0", "/", "~1").Replace(key)
+}
@@ -0,0 +1,77 @@
+package listener
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+	"k8s.io/apimachinery/pkg/util/cache"
+)
+
+const (
+	DefaultRateLimitBurst     = 5
+	DefaultRateLimitCacheSize = 10000
+)
+
+// RateLimiter bounds the commits of every object with a token bucket, so that a controller hot-looping on one object
+// doesn't flood the remote
+type RateLimiter struct {
+	limit rate.Limit
+	burst int
+	ttl   time.Duration
+
+	mu    sync.Mutex
+	cache *cache.LRUExpireCache
+}
+
+// NewRateLimiter creates buckets of burst commits refilled at limit for at most size objects. The bucket of an
+// object is dropped once it would be full again, a new bucket is the same as an idle one.
+func NewRateLimiter(limit rate.Limit, burst, size int) *RateLimiter {
+	return &RateLimiter{
+		limit: limit,
+		burst: burst,
+		ttl:   time.Duration(float64(burst) / float64(limit) * float64(time.Second)),
+		cache: cache.NewLRUExpireCache(size),
+	}
+}
+
+// Allow reports whether the object at key may be committed now and takes a token if so
+func (rl *RateLimiter) Allow(key string) bool {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	limiter := rate.NewLimiter(rl.limit, rl.burst)
+	if v, ok := rl.cache.Get(key); ok {
+		limiter = v.(*rate.Limiter)
+	}
+	rl.cache.Add(key, limiter, rl.ttl)
+
+	return limiter.Allow()
+}
+
+// ParseRate parses a rate of commits, a number per second or a number per unit, e.g. 2, 10/m or 100/h
+func ParseRate(s string) (rate.Limit, error) {
+	count, unit, found := strings.Cut(strings.TrimSpace(s), "/")
+	n, err := strconv.ParseFloat(count, 64)
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("invalid rate %s, must be a positive number", s)
+	}
+
+	per := time.Second
+	if found {
+		switch unit {
+		case "s":
+		case "m":
+			per = time.Minute
+		case "h":
+			per = time.Hour
+		default:
+			return 0, fmt.Errorf("invalid rate %s, unit must be one of s, m, h", s)
+		}
+	}
+
+	return rate.Limit(n / per.Seconds()), nil
+}
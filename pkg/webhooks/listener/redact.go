@@ -0,0 +1,92 @@
+package listener
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+)
+
+// partialRedact masks every scalar under v, keeping the length and a short hash of the value so reviewers can
+// tell a value changed without seeing it
+func partialRedact(v interface{}) interface{} {
+	switch t := v.(type) {
+	case nil:
+		return nil
+	case map[string]interface{}:
+		for k, e := range t {
+			t[k] = partialRedact(e)
+		}
+		return t
+	case []interface{}:
+		for i, e := range t {
+			t[i] = partialRedact(e)
+		}
+		return t
+	case string:
+		return mask(t)
+	default:
+		return mask(fmt.Sprint(t))
+	}
+}
+
+func mask(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return fmt.Sprintf("***(len=%d,sha=%s)", len(s), hex.EncodeToString(sum[:])[:8])
+}
+
+// RedactedValue replaces every scalar under RedactPaths in diffs, records and stored objects
+const RedactedValue = "<redacted>"
+
+// redactPaths replaces RedactPaths of obj in place, redacting twice leaves the same object
+func (l *ListenerWebhook) redactPaths(obj map[string]interface{}) {
+	for _, p := range l.RedactPaths {
+		p.Apply(obj, redact)
+	}
+}
+
+// redact replaces every scalar under v by RedactedValue, keeping the structure
+func redact(v interface{}) interface{} {
+	switch t := v.(type) {
+	case map[string]interface{}:
+		for k, e := range t {
+			t[k] = redact(e)
+		}
+		return t
+	case []interface{}:
+		for i, e := range t {
+			t[i] = redact(e)
+		}
+		return t
+	default:
+		return RedactedValue
+	}
+}
+
+// lastAppliedAnnotation is set by kubectl apply and holds the whole applied object, including the data of secrets
+const lastAppliedAnnotation = "kubectl.kubernetes.io/last-applied-configuration"
+
+// redactSecret replaces the values of data and stringData of secrets by a short hash so that changes still show
+// up in the diff, the applied configuration kept by kubectl would reveal them too and is replaced as a whole
+func redactSecret(obj map[string]interface{}) {
+	if obj["apiVersion"] != "v1" || obj["kind"] != "Secret" {
+		return
+	}
+
+	for _, field := range []string{"data", "stringData"} {
+		values, _ := obj[field].(map[string]interface{})
+		for k, v := range values {
+			values[k] = redacted(fmt.Sprint(v))
+		}
+	}
+
+	metadata, _ := obj["metadata"].(map[string]interface{})
+	annotations, _ := metadata["annotations"].(map[string]interface{})
+	if v, ok := annotations[lastAppliedAnnotation]; ok {
+		annotations[lastAppliedAnnotation] = redacted(fmt.Sprint(v))
+	}
+}
+
+func redacted(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return fmt.Sprintf("<redacted:sha256:%s>", hex.EncodeToString(sum[:])[:12])
+}
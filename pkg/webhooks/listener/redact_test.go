@@ -0,0 +1,127 @@
+package listener
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"testing"
+
+	admissionv1 "k8s.io/api/admission/v1"
+
+	"github.com/reborn1867/k8s-resource-tracer/pkg/backend"
+)
+
+func TestRedactPathsBeforeDiffing(t *testing.T) {
+	p, err := ParsePath("spec.password")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	out := &bytes.Buffer{}
+	l := newTestListener()
+	l.RedactPaths = []Path{p}
+	l.Backends = []backend.Backend{backend.NewStdoutBackend(out)}
+
+	old := deployment(map[string]interface{}{"password": "hunter1", "user": "alice"})
+	obj := deployment(map[string]interface{}{"password": "hunter2", "user": "bob"})
+	if resp := l.handle(context.Background(), request(t, admissionv1.Update, old, obj)); !resp.Allowed {
+		t.Fatalf("expected request to be allowed, got %v", resp.Result)
+	}
+
+	record := out.String()
+	if record == "" {
+		t.Fatal("expected the change of user to be recorded")
+	}
+	for _, secret := range []string{"hunter1", "hunter2"} {
+		if strings.Contains(record, secret) {
+			t.Errorf("redacted value %s leaked into the record: %s", secret, record)
+		}
+	}
+	// json escapes the angle brackets of RedactedValue
+	if !strings.Contains(record, strings.Trim(RedactedValue, "<>")) {
+		t.Errorf("expected %s in the record: %s", RedactedValue, record)
+	}
+}
+
+func TestRedactPathsOnlyChangeIsNotTraced(t *testing.T) {
+	p, err := ParsePath("spec.password")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	out := &bytes.Buffer{}
+	l := newTestListener()
+	l.RedactPaths = []Path{p}
+	l.Backends = []backend.Backend{backend.NewStdoutBackend(out)}
+
+	old := deployment(map[string]interface{}{"password": "hunter1"})
+	obj := deployment(map[string]interface{}{"password": "hunter2"})
+	l.handle(context.Background(), request(t, admissionv1.Update, old, obj))
+
+	if out.Len() != 0 {
+		t.Errorf("expected no record, got %s", out.String())
+	}
+}
+
+func TestPartialRedact(t *testing.T) {
+	cases := []struct {
+		name string
+		a, b interface{}
+		same bool
+	}{
+		{name: "identical strings", a: "hunter2", b: "hunter2", same: true},
+		{name: "changed strings", a: "hunter1", b: "hunter2"},
+		{name: "changed strings of another length", a: "hunter", b: "hunter22"},
+		{name: "identical numbers", a: int64(42), b: int64(42), same: true},
+		{name: "changed nested values", a: map[string]interface{}{"k": []interface{}{"x"}}, b: map[string]interface{}{"k": []interface{}{"y"}}},
+		{name: "identical nested values", a: map[string]interface{}{"k": []interface{}{"x"}}, b: map[string]interface{}{"k": []interface{}{"x"}}, same: true},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			a, b := fmt.Sprint(partialRedact(c.a)), fmt.Sprint(partialRedact(c.b))
+			if (a == b) != c.same {
+				t.Errorf("expected same masks %v, got %s and %s", c.same, a, b)
+			}
+		})
+	}
+}
+
+func TestPartialRedactMask(t *testing.T) {
+	got := partialRedact("hunter2")
+	if !regexp.MustCompile(`^\*\*\*\(len=7,sha=[0-9a-f]{8}\)$`).MatchString(got.(string)) {
+		t.Errorf("expected a mask of length 7, got %s", got)
+	}
+
+	nested := partialRedact(map[string]interface{}{"user": "alice", "tokens": []interface{}{"t1", nil}}).(map[string]interface{})
+	if strings.Contains(fmt.Sprint(nested), "alice") || strings.Contains(fmt.Sprint(nested), "t1") {
+		t.Errorf("expected every scalar to be masked, got %v", nested)
+	}
+	if nested["tokens"].([]interface{})[1] != nil {
+		t.Errorf("expected null to be kept, got %v", nested["tokens"])
+	}
+}
+
+func TestPartialRedactPathsTraceChanges(t *testing.T) {
+	out := &bytes.Buffer{}
+	l := newTestListener()
+	l.PartialRedactPaths = mustParsePaths("spec.password")
+	l.Backends = []backend.Backend{backend.NewStdoutBackend(out)}
+
+	old := deployment(map[string]interface{}{"password": "hunter1"})
+	obj := deployment(map[string]interface{}{"password": "hunter2"})
+	l.handle(context.Background(), request(t, admissionv1.Update, old, obj))
+
+	cs := changes(t, out)
+	if len(cs) != 1 {
+		t.Fatalf("expected the masked change to be traced, got %d changes", len(cs))
+	}
+	if strings.Contains(out.String(), "hunter") {
+		t.Errorf("partially redacted value leaked into the record: %s", out.String())
+	}
+	if !strings.Contains(cs[0].Diff, mask("hunter1")) || !strings.Contains(cs[0].Diff, mask("hunter2")) {
+		t.Errorf("expected the masks of both values in the diff, got %s", cs[0].Diff)
+	}
+}
@@ -0,0 +1,75 @@
+package listener
+
+import (
+	"encoding/json"
+	"strings"
+
+	jd "github.com/josephburnett/jd/lib"
+)
+
+const DefaultLongStringThreshold = 120
+
+// renderDiff renders d the way jd does, except for changes of strings longer than LongStringThreshold
+// which are rendered as a line based unified diff
+func (l *ListenerWebhook) renderDiff(d jd.Diff, opts ...jd.RenderOption) string {
+	color := false
+	for _, o := range opts {
+		if o == jd.COLOR {
+			color = true
+		}
+	}
+
+	var b strings.Builder
+	for _, e := range d {
+		oldText, newText, ok := l.longStrings(e)
+		if !ok {
+			b.WriteString(e.Render(opts...))
+			continue
+		}
+
+		b.WriteString("@ ")
+		b.WriteString(renderPath(e.Path))
+		b.WriteString("\n")
+		b.WriteString(unifiedDiff(oldText, newText, color))
+	}
+
+	return b.String()
+}
+
+func (l *ListenerWebhook) longStrings(e jd.DiffElement) (string, string, bool) {
+	if l.LongStringThreshold <= 0 || len(e.OldValues) != 1 || len(e.NewValues) != 1 {
+		return "", "", false
+	}
+
+	oldText, ok := stringValue(e.OldValues[0])
+	if !ok {
+		return "", "", false
+	}
+	newText, ok := stringValue(e.NewValues[0])
+	if !ok {
+		return "", "", false
+	}
+
+	if len(oldText) <= l.LongStringThreshold && len(newText) <= l.LongStringThreshold {
+		return "", "", false
+	}
+
+	return oldText, newText, true
+}
+
+func stringValue(n jd.JsonNode) (string, bool) {
+	var s string
+	if err := json.Unmarshal([]byte(n.Json()), &s); err != nil {
+		return "", false
+	}
+	return s, true
+}
+
+// renderPath renders the path of a diff element like jd does, e.g. ["spec","template"]
+func renderPath(path []jd.JsonNode) string {
+	elements := make([]string, 0, len(path))
+	for _, p := range path {
+		elements = append(elements, p.Json())
+	}
+	return "[" + strings.Join(elements, ",") + "]"
+}
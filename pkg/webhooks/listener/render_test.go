@@ -0,0 +1,84 @@
+package listener
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	jd "github.com/josephburnett/jd/lib"
+	admissionv1 "k8s.io/api/admission/v1"
+
+	"github.com/reborn1867/k8s-resource-tracer/pkg/backend"
+)
+
+const (
+	oldScript = "#!/bin/sh\nset -e\necho start\nexec server --port 8080\necho done\n"
+	newScript = "#!/bin/sh\nset -e\necho start\nexec server --port 9090\necho done\n"
+)
+
+func TestUnifiedDiff(t *testing.T) {
+	want := "@@ -1,5 +1,5 @@\n" +
+		" #!/bin/sh\n" +
+		" set -e\n" +
+		" echo start\n" +
+		"-exec server --port 8080\n" +
+		"+exec server --port 9090\n" +
+		" echo done\n"
+	if got := unifiedDiff(oldScript, newScript, false); got != want {
+		t.Errorf("expected\n%s\ngot\n%s", want, got)
+	}
+
+	colored := unifiedDiff(oldScript, newScript, true)
+	if !strings.Contains(colored, colorRed+"-exec server --port 8080"+colorDefault) ||
+		!strings.Contains(colored, colorGreen+"+exec server --port 9090"+colorDefault) {
+		t.Errorf("expected colored changed lines, got %q", colored)
+	}
+}
+
+func TestHandleDiffsLongStringsByLine(t *testing.T) {
+	cases := []struct {
+		name      string
+		threshold int
+		unified   bool
+	}{
+		{name: "above the threshold", threshold: 20, unified: true},
+		{name: "below the threshold", threshold: 1000},
+		{name: "disabled"},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			out := &bytes.Buffer{}
+			l := newTestListener()
+			l.LongStringThreshold = c.threshold
+			l.Backends = []backend.Backend{backend.NewStdoutBackend(out)}
+
+			old := deployment(map[string]interface{}{"script": oldScript})
+			obj := deployment(map[string]interface{}{"script": newScript})
+			l.handle(context.Background(), request(t, admissionv1.Update, old, obj))
+
+			cs := changes(t, out)
+			if len(cs) != 1 {
+				t.Fatalf("expected one change, got %d", len(cs))
+			}
+			logged := l.section("spec", mustDiff(t, l, sectionValue(old, "spec"), sectionValue(obj, "spec"))).diff
+			for name, diff := range map[string]string{"stored": cs[0].Diff, "spec section": cs[0].Sections["spec"], "logged": logged} {
+				diff = strings.NewReplacer(colorRed, "", colorGreen, "", colorDefault, "").Replace(diff)
+				lineBased := strings.Contains(diff, "\n-exec server --port 8080\n") && strings.Contains(diff, "\n+exec server --port 9090\n")
+				if lineBased != c.unified {
+					t.Errorf("expected the %s diff line based %v, got %q", name, c.unified, diff)
+				}
+			}
+		})
+	}
+}
+
+func mustDiff(t *testing.T, l *ListenerWebhook, old, new interface{}) jd.Diff {
+	t.Helper()
+	d, err := l.diff([]string{"spec"}, old, new)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return d
+}
@@ -0,0 +1,83 @@
+package listener
+
+import (
+	"encoding/json"
+	"net/http"
+	"path/filepath"
+	"strings"
+
+	"github.com/reborn1867/k8s-resource-tracer/pkg/git"
+)
+
+// objectChange is an entry of the baseline report
+type objectChange struct {
+	git.FileChange
+	App       string `json:"app,omitempty"`
+	Namespace string `json:"namespace"`
+	GVK       string `json:"gvk"`
+	Name      string `json:"name"`
+}
+
+// BaselineReport serves the objects changed since the baseline query parameter, a tag, branch or commit, as json.
+// The optional gvk and namespace query parameters filter the report.
+func (l *ListenerWebhook) BaselineReport() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		baseline := query.Get("baseline")
+		if baseline == "" {
+			http.Error(w, "missing query parameter baseline", http.StatusBadRequest)
+			return
+		}
+
+		changes, err := git.DiffBaseline(l.GitPath, baseline)
+		if err != nil {
+			l.Logger.Error(err, "failed to diff against baseline", "baseline", baseline)
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		report := []objectChange{}
+		for _, c := range changes {
+			o, ok := l.parseStoragePath(c)
+			if !ok {
+				continue
+			}
+			if gvk := query.Get("gvk"); gvk != "" && gvk != o.GVK {
+				continue
+			}
+			if namespace := query.Get("namespace"); namespace != "" && namespace != o.Namespace {
+				continue
+			}
+			report = append(report, o)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(report); err != nil {
+			l.Logger.Error(err, "failed to write baseline report")
+		}
+	})
+}
+
+// parseStoragePath is the reverse of storagePath, files not written by the tracer are skipped
+func (l *ListenerWebhook) parseStoragePath(c git.FileChange) (objectChange, bool) {
+	rel, err := filepath.Rel(filepath.Join("/", l.storageRoot()), filepath.Join("/", c.Path))
+	if err != nil || strings.HasPrefix(rel, "..") || !strings.HasSuffix(rel, l.fileExtension()) {
+		return objectChange{}, false
+	}
+
+	o := objectChange{FileChange: c}
+	parts := strings.Split(strings.TrimSuffix(rel, l.fileExtension()), "/")
+	if len(parts) == 5 && parts[0] == "apps" {
+		o.App = unescapeName(parts[1])
+		parts = parts[2:]
+	}
+	if len(parts) != 3 {
+		return objectChange{}, false
+	}
+
+	o.Namespace, o.GVK, o.Name = unescapeName(parts[0]), parts[1], unescapeName(parts[2])
+	if parts[0] == ClusterScopeDir {
+		o.Namespace = ""
+	}
+	return o, true
+}
@@ -0,0 +1,25 @@
+package listener
+
+// DefaultStoredMetadataFields keeps stored files usable as manifests, server managed fields like uid,
+// resourceVersion or creationTimestamp are dropped
+var DefaultStoredMetadataFields = []string{"name", "namespace", "labels", "annotations"}
+
+// allMetadataFields in StoredMetadataFields keeps every metadata field except managedFields
+const allMetadataFields = "*"
+
+// sanitizeMetadata replaces the metadata of obj by a copy holding only the fields to be stored
+func (l *ListenerWebhook) sanitizeMetadata(obj map[string]interface{}) {
+	keep := map[string]bool{}
+	for _, f := range l.StoredMetadataFields {
+		keep[f] = true
+	}
+
+	metadata, _ := obj["metadata"].(map[string]interface{})
+	sanitized := l.stripIgnoredFields(metadata)
+	for k := range sanitized {
+		if !keep[allMetadataFields] && !keep[k] {
+			delete(sanitized, k)
+		}
+	}
+	obj["metadata"] = sanitized
+}
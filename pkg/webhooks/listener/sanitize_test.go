@@ -0,0 +1,84 @@
+package listener
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	admissionv1 "k8s.io/api/admission/v1"
+	"sigs.k8s.io/yaml"
+)
+
+func TestStoredMetadata(t *testing.T) {
+	serverFields := map[string]interface{}{
+		"uid":               "6f1c2d3e",
+		"resourceVersion":   "42",
+		"generation":        int64(3),
+		"creationTimestamp": "2024-01-01T00:00:00Z",
+		"selfLink":          "/apis/apps/v1/namespaces/default/deployments/app",
+		"managedFields":     []interface{}{map[string]interface{}{"manager": "kubectl", "operation": "Update"}},
+	}
+
+	cases := []struct {
+		name   string
+		fields []string
+		want   []string
+	}{
+		{name: "default", fields: DefaultStoredMetadataFields, want: []string{"name", "namespace", "labels", "annotations"}},
+		{name: "configured", fields: []string{"name", "namespace", "uid"}, want: []string{"name", "namespace", "uid"}},
+		{name: "all but managedFields", fields: []string{allMetadataFields},
+			want: []string{"name", "namespace", "labels", "annotations", "uid", "resourceVersion", "generation", "creationTimestamp", "selfLink"}},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			l, repo, _ := gitListener(t)
+			l.StoredMetadataFields = c.fields
+
+			obj := deployment(map[string]interface{}{"replicas": int64(1)})
+			metadata := obj["metadata"].(map[string]interface{})
+			metadata["labels"] = map[string]interface{}{"app": "web"}
+			metadata["annotations"] = map[string]interface{}{"team": "platform"}
+			for k, v := range serverFields {
+				metadata[k] = v
+			}
+			if resp := l.handle(context.Background(), request(t, admissionv1.Create, nil, obj)); !resp.Allowed {
+				t.Fatalf("expected request to be allowed, got %v", resp.Result)
+			}
+
+			head, err := repo.Head()
+			if err != nil {
+				t.Fatal(err)
+			}
+			commit, err := repo.CommitObject(head.Hash())
+			if err != nil {
+				t.Fatal(err)
+			}
+			f, err := commit.File(filepath.Join("default", "apps-v1.Deployment", "app.yaml"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			contents, err := f.Contents()
+			if err != nil {
+				t.Fatal(err)
+			}
+			stored := map[string]interface{}{}
+			if err := yaml.Unmarshal([]byte(contents), &stored); err != nil {
+				t.Fatal(err)
+			}
+
+			storedMetadata, _ := stored["metadata"].(map[string]interface{})
+			if len(storedMetadata) != len(c.want) {
+				t.Errorf("expected metadata fields %v, got %v", c.want, storedMetadata)
+			}
+			for _, k := range c.want {
+				if _, ok := storedMetadata[k]; !ok {
+					t.Errorf("expected metadata field %s to be stored, got %v", k, storedMetadata)
+				}
+			}
+			if _, ok := stored["spec"]; !ok {
+				t.Errorf("expected the spec to be stored, got %v", stored)
+			}
+		})
+	}
+}
@@ -0,0 +1,73 @@
+package listener
+
+import (
+	jd "github.com/josephburnett/jd/lib"
+)
+
+// DefaultMetadataDiffFields are merged into the metadata section by MergeMetadataDiff
+var DefaultMetadataDiffFields = []string{"labels", "annotations"}
+
+// diffSection is a rendered part of the diff of an object
+type diffSection struct {
+	name    string
+	diff    string
+	changes jd.Diff
+}
+
+// sectionValue returns the value of key in obj as it is, scalars and lists included, a missing or null value is
+// empty so that every kind of object, with or without spec or status, is diffed the same way
+func sectionValue(obj map[string]interface{}, key string) interface{} {
+	if v, ok := obj[key]; ok && v != nil {
+		return v
+	}
+	return map[string]interface{}{}
+}
+
+func changed(sections []diffSection) bool {
+	for _, s := range sections {
+		if s.diff != "" {
+			return true
+		}
+	}
+	return false
+}
+
+func diffSize(sections []diffSection) int {
+	size := 0
+	for _, s := range sections {
+		size += len(s.diff)
+	}
+	return size
+}
+
+// metadataDiff diffs MetadataDiffFields as a single section
+func (l *ListenerWebhook) metadataDiff(oldMetadata, newMetadata map[string]interface{}) (jd.Diff, error) {
+	fields := l.MetadataDiffFields
+	if len(fields) == 0 {
+		fields = DefaultMetadataDiffFields
+	}
+
+	oldFields, newFields := map[string]interface{}{}, map[string]interface{}{}
+	for _, f := range fields {
+		if l.strips(f) {
+			continue
+		}
+		if v, ok := oldMetadata[f]; ok {
+			oldFields[f] = v
+		}
+		if v, ok := newMetadata[f]; ok {
+			newFields[f] = v
+		}
+	}
+
+	oldNode, err := jd.NewJsonNode(oldFields)
+	if err != nil {
+		return nil, err
+	}
+	newNode, err := jd.NewJsonNode(newFields)
+	if err != nil {
+		return nil, err
+	}
+
+	return oldNode.Diff(newNode, l.diffMetadata()...), nil
+}
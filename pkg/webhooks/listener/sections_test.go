@@ -0,0 +1,109 @@
+package listener
+
+import (
+	"bytes"
+	"context"
+	"reflect"
+	"testing"
+
+	admissionv1 "k8s.io/api/admission/v1"
+
+	"github.com/reborn1867/k8s-resource-tracer/pkg/backend"
+)
+
+func TestSectionValue(t *testing.T) {
+	cases := []struct {
+		name string
+		obj  map[string]interface{}
+		want interface{}
+	}{
+		{name: "absent", obj: map[string]interface{}{}, want: map[string]interface{}{}},
+		{name: "null", obj: map[string]interface{}{"spec": nil}, want: map[string]interface{}{}},
+		{name: "map", obj: map[string]interface{}{"spec": map[string]interface{}{"a": "b"}}, want: map[string]interface{}{"a": "b"}},
+		{name: "list", obj: map[string]interface{}{"spec": []interface{}{"a", "b"}}, want: []interface{}{"a", "b"}},
+		{name: "scalar", obj: map[string]interface{}{"spec": "a"}, want: "a"},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if got := sectionValue(c.obj, "spec"); !reflect.DeepEqual(got, c.want) {
+				t.Errorf("expected %v, got %v", c.want, got)
+			}
+		})
+	}
+}
+
+func TestHandleIrregularSections(t *testing.T) {
+	withMetadata := func(obj map[string]interface{}, labels, annotations interface{}) map[string]interface{} {
+		metadata := obj["metadata"].(map[string]interface{})
+		if labels != nil {
+			metadata["labels"] = labels
+		}
+		if annotations != nil {
+			metadata["annotations"] = annotations
+		}
+		return obj
+	}
+	without := func(obj map[string]interface{}, key string) map[string]interface{} {
+		delete(obj, key)
+		return obj
+	}
+
+	cases := []struct {
+		name    string
+		old     map[string]interface{}
+		obj     map[string]interface{}
+		changed bool
+	}{
+		{name: "absent spec", old: without(deployment(nil), "spec"), obj: without(deployment(nil), "spec")},
+		{name: "spec added", old: without(deployment(nil), "spec"), obj: deployment(map[string]interface{}{"replicas": int64(1)}), changed: true},
+		{name: "absent status", old: deployment(map[string]interface{}{"replicas": int64(1)}), obj: deployment(map[string]interface{}{"replicas": int64(2)}), changed: true},
+		{name: "status added", old: deployment(nil), obj: func() map[string]interface{} {
+			obj := deployment(nil)
+			obj["status"] = map[string]interface{}{"ready": true}
+			return obj
+		}(), changed: true},
+		{name: "list spec", old: func() map[string]interface{} {
+			obj := deployment(nil)
+			obj["spec"] = []interface{}{"a"}
+			return obj
+		}(), obj: func() map[string]interface{} {
+			obj := deployment(nil)
+			obj["spec"] = []interface{}{"a", "b"}
+			return obj
+		}(), changed: true},
+		{name: "scalar spec", old: func() map[string]interface{} {
+			obj := deployment(nil)
+			obj["spec"] = "a"
+			return obj
+		}(), obj: func() map[string]interface{} {
+			obj := deployment(nil)
+			obj["spec"] = int64(1)
+			return obj
+		}(), changed: true},
+		{name: "spec changes type", old: deployment(map[string]interface{}{"a": "b"}), obj: func() map[string]interface{} {
+			obj := deployment(nil)
+			obj["spec"] = []interface{}{"a"}
+			return obj
+		}(), changed: true},
+		{name: "labels added", old: deployment(nil), obj: withMetadata(deployment(nil), map[string]interface{}{"a": "b"}, nil), changed: true},
+		{name: "annotations removed", old: withMetadata(deployment(nil), nil, map[string]interface{}{"a": "b"}), obj: deployment(nil), changed: true},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			out := &bytes.Buffer{}
+			l := newTestListener()
+			l.FailurePolicy = FailureClosed
+			l.Backends = []backend.Backend{backend.NewStdoutBackend(out)}
+
+			resp := l.handle(context.Background(), request(t, admissionv1.Update, c.old, c.obj))
+			if !resp.Allowed {
+				t.Fatalf("expected request to be allowed, got %v", resp.Result)
+			}
+			if changed := out.Len() > 0; changed != c.changed {
+				t.Errorf("expected changed %v, got %v: %s", c.changed, changed, out.String())
+			}
+		})
+	}
+}
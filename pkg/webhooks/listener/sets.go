@@ -0,0 +1,130 @@
+package listener
+
+import (
+	"fmt"
+	"strings"
+
+	jd "github.com/josephburnett/jd/lib"
+)
+
+// SetPath is a list diffed regardless of the order of its items. Items are matched by Keys like jd's setkeys,
+// without keys the list is diffed as a multiset. Lists nested in the items are diffed the same way.
+type SetPath struct {
+	Path   Path
+	Keys   []string
+	fields []string
+}
+
+// ParseSetPath parses path=key,key or path, the path must only consist of keys, e.g. spec.template.spec.containers=name
+func ParseSetPath(raw string) (SetPath, error) {
+	rawPath, rawKeys, _ := strings.Cut(raw, "=")
+	p, err := ParsePath(rawPath)
+	if err != nil {
+		return SetPath{}, err
+	}
+
+	s := SetPath{Path: p}
+	for _, seg := range p.segments {
+		if seg.kind != keySegment || seg.key == "*" {
+			return SetPath{}, fmt.Errorf("set path %s must not contain indexes or wildcards", rawPath)
+		}
+		s.fields = append(s.fields, seg.key)
+	}
+	for _, k := range strings.Split(rawKeys, ",") {
+		if k = strings.TrimSpace(k); k != "" {
+			s.Keys = append(s.Keys, k)
+		}
+	}
+
+	return s, nil
+}
+
+func (s SetPath) metadata() []jd.Metadata {
+	if len(s.Keys) == 0 {
+		return []jd.Metadata{jd.MULTISET}
+	}
+	return []jd.Metadata{jd.SET, jd.Setkeys(s.Keys...)}
+}
+
+// diff diffs the values old and new found at prefix in the objects, the SetKeys below prefix are diffed with
+// set semantics and the rest positionally
+func (l *ListenerWebhook) diff(prefix []string, old, new interface{}) (jd.Diff, error) {
+	type relativeSet struct {
+		keys     []string
+		metadata []jd.Metadata
+	}
+	var sets []relativeSet
+	for _, s := range l.SetKeys {
+		if len(s.fields) > len(prefix) && strings.Join(s.fields[:len(prefix)], ".") == strings.Join(prefix, ".") {
+			sets = append(sets, relativeSet{keys: s.fields[len(prefix):], metadata: s.metadata()})
+		}
+	}
+
+	var setDiffs jd.Diff
+	for _, s := range sets {
+		oldNode, err := jd.NewJsonNode(valueAt(old, s.keys))
+		if err != nil {
+			return nil, err
+		}
+		newNode, err := jd.NewJsonNode(valueAt(new, s.keys))
+		if err != nil {
+			return nil, err
+		}
+
+		for _, e := range oldNode.Diff(newNode, append(s.metadata, l.diffMetadata()...)...) {
+			path := make([]jd.JsonNode, 0, len(s.keys)+len(e.Path))
+			for _, k := range s.keys {
+				key, _ := jd.NewJsonNode(k)
+				path = append(path, key)
+			}
+			e.Path = append(path, e.Path...)
+			setDiffs = append(setDiffs, e)
+		}
+		old, new = withoutValueAt(old, s.keys), withoutValueAt(new, s.keys)
+	}
+
+	oldNode, err := jd.NewJsonNode(old)
+	if err != nil {
+		return nil, err
+	}
+	newNode, err := jd.NewJsonNode(new)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(oldNode.Diff(newNode, l.diffMetadata()...), setDiffs...), nil
+}
+
+func valueAt(v interface{}, keys []string) interface{} {
+	for _, k := range keys {
+		m, ok := v.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		v = m[k]
+	}
+	return v
+}
+
+// withoutValueAt returns a copy of v without the value at keys, only the maps on the way are copied
+func withoutValueAt(v interface{}, keys []string) interface{} {
+	m, ok := v.(map[string]interface{})
+	if !ok {
+		return v
+	}
+	if _, ok := m[keys[0]]; !ok {
+		return v
+	}
+
+	copied := make(map[string]interface{}, len(m))
+	for k, value := range m {
+		copied[k] = value
+	}
+	if len(keys) == 1 {
+		delete(copied, keys[0])
+	} else {
+		copied[keys[0]] = withoutValueAt(m[keys[0]], keys[1:])
+	}
+
+	return copied
+}
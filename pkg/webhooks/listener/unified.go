@@ -0,0 +1,113 @@
+package listener
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/go-git/go-git/v5/utils/diff"
+	"github.com/sergi/go-diff/diffmatchpatch"
+)
+
+const (
+	unifiedContext = 3
+
+	colorDefault = "\033[0m"
+	colorRed     = "\033[31m"
+	colorGreen   = "\033[32m"
+)
+
+type diffLine struct {
+	op   byte
+	text string
+}
+
+// unifiedDiff renders a line based diff between oldText and newText in unified format
+func unifiedDiff(oldText, newText string, color bool) string {
+	var lines []diffLine
+	for _, d := range diff.Do(oldText, newText) {
+		op := byte(' ')
+		switch d.Type {
+		case diffmatchpatch.DiffDelete:
+			op = '-'
+		case diffmatchpatch.DiffInsert:
+			op = '+'
+		}
+
+		for _, text := range strings.SplitAfter(d.Text, "\n") {
+			if text != "" {
+				lines = append(lines, diffLine{op: op, text: strings.TrimSuffix(text, "\n")})
+			}
+		}
+	}
+
+	// line numbers in the old and new text before lines[i]
+	oldNo := make([]int, len(lines)+1)
+	newNo := make([]int, len(lines)+1)
+	for i, line := range lines {
+		oldNo[i+1], newNo[i+1] = oldNo[i], newNo[i]
+		if line.op != '+' {
+			oldNo[i+1]++
+		}
+		if line.op != '-' {
+			newNo[i+1]++
+		}
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(lines); {
+		if lines[i].op == ' ' {
+			i++
+			continue
+		}
+
+		// merge changes whose context overlaps into one hunk
+		start, end := max(0, i-unifiedContext), i
+		for j := i + 1; j < len(lines) && j <= end+2*unifiedContext+1; j++ {
+			if lines[j].op != ' ' {
+				end = j
+			}
+		}
+		stop := min(len(lines), end+unifiedContext+1)
+
+		fmt.Fprintf(&b, "@@ -%s +%s @@\n", hunkRange(oldNo[start], oldNo[stop]), hunkRange(newNo[start], newNo[stop]))
+		for _, line := range lines[start:stop] {
+			switch {
+			case color && line.op == '-':
+				fmt.Fprintf(&b, "%s-%s%s\n", colorRed, line.text, colorDefault)
+			case color && line.op == '+':
+				fmt.Fprintf(&b, "%s+%s%s\n", colorGreen, line.text, colorDefault)
+			default:
+				fmt.Fprintf(&b, "%c%s\n", line.op, line.text)
+			}
+		}
+		i = stop
+	}
+
+	return b.String()
+}
+
+// hunkRange renders the lines from, exclusive, to to of a hunk, empty ranges start at the line before them
+func hunkRange(from, to int) string {
+	if to == from {
+		return fmt.Sprintf("%d,0", from)
+	}
+	return fmt.Sprintf("%d,%d", from+1, to-from)
+}
+
+// unifiedPatch renders the change of the file at path from oldText to newText as git diff does, empty texts
+// mean the file is created or deleted
+func unifiedPatch(path, oldText, newText string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "diff --git a/%s b/%s\n", path, path)
+	switch {
+	case oldText == "":
+		fmt.Fprintf(&b, "new file mode 100644\n--- /dev/null\n+++ b/%s\n", path)
+	case newText == "":
+		fmt.Fprintf(&b, "deleted file mode 100644\n--- a/%s\n+++ /dev/null\n", path)
+	default:
+		fmt.Fprintf(&b, "--- a/%s\n+++ b/%s\n", path, path)
+	}
+	b.WriteString(unifiedDiff(oldText, newText, false))
+
+	return b.String()
+}